@@ -0,0 +1,73 @@
+// Command gossr-types converts Go props structs to TypeScript types, and TypeScript request types to Go structs
+//
+// It writes the files that the gossr dev server generates, or with -check compares them with the committed files
+// and exits with status 1 and a diff when they are out of date:
+//
+//	go run github.com/yejune/gotossr/cmd/gossr-types -props ./models/props.go -types ./frontend/src/generated.d.ts -check
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yejune/gotossr/internal/typeconverter"
+)
+
+// typeMappings collects the repeated -map flags
+type typeMappings map[string]string
+
+func (m typeMappings) String() string {
+	return fmt.Sprint(map[string]string(m))
+}
+
+func (m typeMappings) Set(value string) error {
+	goType, tsType, ok := strings.Cut(value, "=")
+	if !ok || goType == "" || tsType == "" {
+		return fmt.Errorf("expected pkgpath.Type=tsType, got %q", value)
+	}
+	m[goType] = tsType
+	return nil
+}
+
+func main() {
+	mappings := typeMappings{}
+	var config typeconverter.Config
+	flag.StringVar(&config.StructsFilePath, "props", "", "path to the Go props structs file(s), comma-separated")
+	flag.StringVar(&config.GeneratedTypesPath, "types", "", "path of the generated TypeScript types")
+	flag.StringVar(&config.SchemaPath, "schema", "", "path of the generated JSON Schema (optional)")
+	flag.StringVar(&config.ZodPath, "zod", "", "path of the generated Zod schemas (optional)")
+	flag.StringVar(&config.TypeScriptFilesPath, "ts", "", "path to the TypeScript request types file(s) to convert to Go structs, comma-separated")
+	flag.StringVar(&config.GoStructsPath, "go", "", "path of the generated Go structs")
+	flag.Var(mappings, "map", "TypeScript type of a Go type, e.g. github.com/google/uuid.UUID=string (repeatable)")
+	check := flag.Bool("check", false, "compare the generated files with the existing ones instead of writing them")
+	flag.Parse()
+
+	if (config.StructsFilePath == "") != (config.GeneratedTypesPath == "") || (config.TypeScriptFilesPath == "") != (config.GoStructsPath == "") ||
+		config.StructsFilePath == "" && config.TypeScriptFilesPath == "" {
+		fmt.Fprintln(os.Stderr, "gossr-types: -props and -types, or -ts and -go are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	config.TypeMappings = mappings
+
+	if !*check {
+		if _, err := typeconverter.Start(config); err != nil {
+			fmt.Fprintln(os.Stderr, "gossr-types:", err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	diff, err := typeconverter.Check(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gossr-types:", err)
+		os.Exit(2)
+	}
+	if diff != "" {
+		fmt.Print(diff)
+		fmt.Fprintln(os.Stderr, "gossr-types: generated types are out of date, run gossr-types without -check to regenerate them")
+		os.Exit(1)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/yejune/gotossr/internal/cache"
 	"github.com/yejune/gotossr/internal/utils"
@@ -20,8 +21,12 @@ type Config struct {
 	LayoutFilePath      string            // The path to the layout file, relative to the frontend dir
 	LayoutCSSFilePath   string            // The path to the layout css file, relative to the frontend dir
 	TailwindConfigPath  string            // The path to the tailwind config file
-	HotReloadServerPort int               // The port to run the hot reload server on, 3001 by default
-	JSRuntimePoolSize   int               // The number of JS runtimes to keep in the pool, 10 by default
+	HotReloadServerPort int               // The port to run the standalone hot reload server on, 3001 by default
+	HotReloadPath       string            // The path the hot reload websocket is served on, "/ws" by default
+	HotReloadEmbedded   bool              // Skip the standalone hot reload server, mount Engine.HotReloadHandler at HotReloadPath on your router instead
+	HotReloadHost       string            // The host the standalone hot reload server listens on, "127.0.0.1" by default
+	JSRuntimePoolSize   int               // The maximum number of JS runtimes in the pool, 10 by default
+	JSRuntime           string            // The JS runtime to render with ("v8", "quickjs", "goja", "moderncjs" or "dukgo"), the fastest one compiled in by default
 	CacheConfig         cache.CacheConfig // Cache configuration (local or redis)
 	ClientAppPath       string            // Path to client SPA app (e.g., "App.tsx") for client-side routing after hydration
 	// SPA hydration mode options (only used when ClientAppPath is set):
@@ -33,6 +38,69 @@ type Config struct {
 	// This enables browser caching - the React library bundle rarely changes and can be cached.
 	StaticJSDir string // Directory to write JS files (e.g., "frontend/dist/assets"). If empty, JS is inlined.
 	IsDev       bool   // Development mode - enables hot reload, disables caching
+	// ImportMap maps package specifiers to URLs, e.g. {"react": "https://cdn.example.com/react.mjs"}.
+	// Listed packages are left out of client bundles and loaded by the browser through a
+	// <script type="importmap">. Server bundles still include them so SSR keeps working.
+	// Subpath imports need their own entry or a trailing-slash prefix (e.g. "react-dom/").
+	ImportMap map[string]string
+	// EditorURL is the link template used by the dev error overlay to open the failing file.
+	// {file}, {line} and {column} are replaced with the error location.
+	// Defaults to "vscode://file{file}:{line}:{column}" ({file} is an absolute path)
+	EditorURL string
+	// HotReloadAllowedOrigins lists extra origins (e.g. "https://dev.example.com") allowed to connect to hot reload.
+	// Pages served from localhost, a loopback address or HotReloadHost are always allowed.
+	HotReloadAllowedOrigins []string
+	// HotReloadMaxConnections is the maximum number of open hot reload connections, 100 by default
+	HotReloadMaxConnections int
+	// HotReloadIgnore lists glob patterns of files and directories the dev file watcher ignores, matched against
+	// their name and their path relative to the frontend dir. Defaults to node_modules, .git, dist and build.
+	HotReloadIgnore []string
+	// HotReloadDebounce is how long the file watcher waits for more changes before rebuilding, 100ms by default
+	HotReloadDebounce time.Duration
+	// TypeMappings maps Go types to the TypeScript types generated for them, keyed by package path and type name,
+	// e.g. {"github.com/google/uuid.UUID": "string"}. time.Time is mapped to string by default.
+	TypeMappings map[string]string
+	// PropsSchemaPath and PropsZodPath are where the JSON Schema and the Zod schemas of the props structs are written
+	// alongside the generated types, e.g. "./frontend/src/generated.schema.json" and "./frontend/src/generated.zod.ts".
+	// Nothing is written if they are empty. Zod schemas import "zod", which must be installed in the frontend.
+	PropsSchemaPath string
+	PropsZodPath    string
+	// RequestTypesPath lists TypeScript files, comma-separated, whose exported interfaces and type aliases are
+	// converted to Go structs with JSON tags in GeneratedStructsPath, e.g. the payloads of forms posted back to Go.
+	// The package of the generated file is the one of the other Go files in its directory.
+	RequestTypesPath     string
+	GeneratedStructsPath string
+	// JSRuntimeMinPoolSize is the number of JS runtimes created upfront and kept when idle, JSRuntimePoolSize by default.
+	// More runtimes are created on demand up to JSRuntimePoolSize when renders are waiting.
+	JSRuntimeMinPoolSize int
+	// JSRuntimeIdleTimeout is how long runtimes beyond JSRuntimeMinPoolSize stay idle before they are destroyed, never by default
+	JSRuntimeIdleTimeout time.Duration
+	// JSRuntimeMaxQueue is the maximum number of renders waiting for a runtime, unlimited by default.
+	// Renders beyond it fail right away with an error page instead of piling up on an overloaded server.
+	JSRuntimeMaxQueue int
+	// JSRuntimeHeapLimit is the maximum heap size of each JS runtime in bytes, the runtime's default by default
+	// (256MB for QuickJS). goja and dukgo have no heap limit.
+	JSRuntimeHeapLimit uint64
+	// JSRuntimeHeapRecycleRatio is the fraction of its heap limit a runtime may use after a render before it is
+	// destroyed and replaced, 0.8 by default. Runtimes that still run out of memory are recycled too.
+	JSRuntimeHeapRecycleRatio float64
+	// JSRuntimeIsolation keeps the globals a render assigns, e.g. a store holding user data, from the next renders.
+	// V8 reuses its context between renders by default, the other runtimes start every render with a new one.
+	// - "": no isolation, fastest. Globals assigned by renders are logged in development.
+	// - "globals": deletes the globals each render assigned. Changes to existing globals are kept.
+	// - "context": renders each request in a new context
+	JSRuntimeIsolation string
+	// JSRuntimeCodeCache persists the code V8 compiles from bundles, which the runtimes of the pool share in memory,
+	// up to 64MB of the most recently used code. Development keeps nothing in memory as every edit changes bundles.
+	// - "": in memory only
+	// - "disk": in JSRuntimeCodeCacheDir, so restarts skip compilation. The least recently used code beyond 256MB is removed.
+	// - "cache": in the cache of CacheConfig, e.g. shared by the servers using Redis. Same as "" with the local cache.
+	JSRuntimeCodeCache    string
+	JSRuntimeCodeCacheDir string // Directory of the "disk" code cache, in the user cache dir by default
+
+	// WarmupFiles are built and compiled in the JS runtimes by New, see Engine.Warmup.
+	// Entries are relative to FrontendDir and may be glob patterns, e.g. "pages/*.tsx" for every page.
+	WarmupFiles []string
 
 	// Generators are custom code generators that run during engine initialization (dev mode only)
 	// Use this to generate routes, API clients, or any other code based on the SSR configuration
@@ -53,6 +121,17 @@ func (c *Config) Validate() error {
 			}
 		}
 	}
+	if c.RequestTypesPath != "" {
+		if c.GeneratedStructsPath == "" {
+			return fmt.Errorf("generated structs path must be provided when using request types")
+		}
+		for _, p := range strings.Split(c.RequestTypesPath, ",") {
+			p = strings.TrimSpace(p)
+			if p != "" && !checkPathExists(p) {
+				return fmt.Errorf("request types path at %s does not exist", p)
+			}
+		}
+	}
 	if c.LayoutFilePath != "" && !checkPathExists(path.Join(c.FrontendDir, c.LayoutFilePath)) {
 		return fmt.Errorf("layout file path at %s/%s does not exist", c.FrontendDir, c.LayoutFilePath)
 	}
@@ -68,9 +147,46 @@ func (c *Config) Validate() error {
 	if c.HotReloadServerPort == 0 {
 		c.HotReloadServerPort = 3001
 	}
+	if c.HotReloadPath == "" {
+		c.HotReloadPath = "/ws"
+	}
+	if c.HotReloadHost == "" {
+		c.HotReloadHost = "127.0.0.1"
+	}
+	if c.HotReloadMaxConnections == 0 {
+		c.HotReloadMaxConnections = 100
+	}
+	if c.HotReloadIgnore == nil {
+		c.HotReloadIgnore = []string{"node_modules", ".git", "dist", "build"}
+	}
+	for _, pattern := range c.HotReloadIgnore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid hot reload ignore pattern %s: %w", pattern, err)
+		}
+	}
+	if c.HotReloadDebounce == 0 {
+		c.HotReloadDebounce = 100 * time.Millisecond
+	}
+	if !strings.HasPrefix(c.HotReloadPath, "/") {
+		return fmt.Errorf("hot reload path %s must start with /", c.HotReloadPath)
+	}
+	if c.EditorURL == "" {
+		c.EditorURL = "vscode://file{file}:{line}:{column}"
+	}
 	if c.JSRuntimePoolSize == 0 {
 		c.JSRuntimePoolSize = 10
 	}
+	if c.JSRuntimeHeapRecycleRatio < 0 || c.JSRuntimeHeapRecycleRatio > 1 {
+		return fmt.Errorf("JS runtime heap recycle ratio %v must be between 0 and 1", c.JSRuntimeHeapRecycleRatio)
+	}
+	if c.JSRuntimeMinPoolSize > c.JSRuntimePoolSize {
+		return fmt.Errorf("JS runtime min pool size %d is larger than the pool size %d", c.JSRuntimeMinPoolSize, c.JSRuntimePoolSize)
+	}
+	switch c.JSRuntimeCodeCache {
+	case "", "disk", "cache":
+	default:
+		return fmt.Errorf("unknown JS runtime code cache %q, must be \"disk\" or \"cache\"", c.JSRuntimeCodeCache)
+	}
 	// Default SPA hydration mode to "router" for true hydration with React Router
 	if c.ClientAppPath != "" && c.SPAHydrationMode == "" {
 		c.SPAHydrationMode = "router"
@@ -82,6 +198,11 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("failed to create static js dir at %s: %w", c.StaticJSDir, err)
 		}
 	}
+	for specifier, url := range c.ImportMap {
+		if specifier == "" || url == "" {
+			return fmt.Errorf("import map entry %q -> %q must have a package and a url", specifier, url)
+		}
+	}
 	c.setFilePaths()
 	return nil
 }
@@ -90,6 +211,12 @@ func (c *Config) Validate() error {
 func (c *Config) setFilePaths() {
 	c.FrontendDir = utils.GetFullFilePath(c.FrontendDir)
 	c.GeneratedTypesPath = utils.GetFullFilePath(c.GeneratedTypesPath)
+	if c.PropsSchemaPath != "" {
+		c.PropsSchemaPath = utils.GetFullFilePath(c.PropsSchemaPath)
+	}
+	if c.PropsZodPath != "" {
+		c.PropsZodPath = utils.GetFullFilePath(c.PropsZodPath)
+	}
 	// Handle multiple props struct paths
 	if c.PropsStructsPath != "" {
 		var fullPaths []string
@@ -101,6 +228,18 @@ func (c *Config) setFilePaths() {
 		}
 		c.PropsStructsPath = strings.Join(fullPaths, ",")
 	}
+	if c.RequestTypesPath != "" {
+		var fullPaths []string
+		for _, p := range strings.Split(c.RequestTypesPath, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				fullPaths = append(fullPaths, utils.GetFullFilePath(p))
+			}
+		}
+		c.RequestTypesPath = strings.Join(fullPaths, ",")
+	}
+	if c.GeneratedStructsPath != "" {
+		c.GeneratedStructsPath = utils.GetFullFilePath(c.GeneratedStructsPath)
+	}
 	if c.LayoutFilePath != "" {
 		c.LayoutFilePath = path.Join(c.FrontendDir, c.LayoutFilePath)
 	}
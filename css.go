@@ -16,7 +16,8 @@ import (
 
 // BuildLayoutCSSFile builds the layout css file if it exists
 func (engine *Engine) BuildLayoutCSSFile() error {
-	if engine.CachedLayoutCSSFilePath == "" && engine.Config.LayoutCSSFilePath != "" {
+	// Without tailwind the cached file is a plain copy, so copy it again to pick up changes
+	if engine.Config.LayoutCSSFilePath != "" && (engine.CachedLayoutCSSFilePath == "" || engine.Config.TailwindConfigPath == "") {
 		layoutCSSCacheDir, err := utils.GetCSSCacheDir()
 		if err != nil {
 			return err
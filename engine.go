@@ -2,8 +2,15 @@ package go_ssr
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
 	"log/slog"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/yejune/gotossr/internal/cache"
 	"github.com/yejune/gotossr/internal/jsruntime"
@@ -12,17 +19,28 @@ import (
 )
 
 type Engine struct {
-	Logger                  *slog.Logger
-	Config                  *Config
-	HotReload               *HotReload
-	Cache                   cache.Cache
-	RuntimePool             *jsruntime.Pool
-	CachedLayoutCSSFilePath string
-	CachedClientSPAJS       string // Cached client SPA bundle JS
-	CachedServerSPAJS       string // Cached server SPA bundle JS (for StaticRouter rendering)
-	CachedServerSPACSS      string // Cached server SPA bundle CSS
+	Logger                   *slog.Logger
+	Config                   *Config
+	HotReload                *HotReload
+	Cache                    cache.Cache
+	RuntimePool              *jsruntime.Pool
+	CachedLayoutCSSFilePath  string
+	CachedClientSPAJS        string // Cached client SPA bundle JS
+	CachedServerSPAJS        string // Cached server SPA bundle JS (for StaticRouter rendering)
+	CachedServerSPACSS       string // Cached server SPA bundle CSS
+	CachedServerSPASourceMap string // Source map of the cached server SPA bundle, used to remap render errors
+	hmrEnabled               bool   // Client bundles are built with React Refresh for hot updates (dev only)
+
+	// propsSchema holds the *typeconverter.Schema of the props structs, used to validate rendered props (dev only)
+	propsSchema atomic.Value
+	// pages holds the props type names of the pages declared with NewPage by file
+	pages   map[string]string
+	pagesMu sync.Mutex
 }
 
+// ErrTypesOutdated is returned by CheckTypes when the generated files don't match the props structs
+var ErrTypesOutdated = errors.New("generated types are out of date")
+
 // IsProduction returns true if running in production mode
 func (engine *Engine) IsProduction() bool {
 	return engine.Config.AppEnv == "production"
@@ -56,13 +74,37 @@ func New(config Config) (*Engine, error) {
 		Cache:  cacheInstance,
 	}
 
+	codeCache, err := engine.persistentCodeCache()
+	if err != nil {
+		logger.Error("Failed to initialize JS runtime code cache", "error", err)
+		return nil, err
+	}
+
 	// Initialize the JS runtime pool after validation (defaults are now set)
-	engine.RuntimePool = jsruntime.NewPool(jsruntime.PoolConfig{
-		PoolSize: config.JSRuntimePoolSize,
+	engine.RuntimePool, err = jsruntime.NewPool(jsruntime.PoolConfig{
+		RuntimeType: jsruntime.RuntimeType(config.JSRuntime),
+		PoolSize:    config.JSRuntimePoolSize,
+		MinSize:     config.JSRuntimeMinPoolSize,
+		IdleTimeout: config.JSRuntimeIdleTimeout,
+		MaxQueue:    config.JSRuntimeMaxQueue,
+
+		HeapLimit:        config.JSRuntimeHeapLimit,
+		HeapRecycleRatio: config.JSRuntimeHeapRecycleRatio,
+
+		Isolation:    jsruntime.Isolation(config.JSRuntimeIsolation),
+		OnGlobalLeak: engine.globalLeakWarning(),
+		CodeCache:    codeCache,
+		// Every edit changes the bundles, so their compiled code would pile up in memory
+		SkipMemoryCodeCache: engine.hotReloadEnabled(),
 	})
+	if err != nil {
+		logger.Error("Failed to initialize JS runtime pool", "error", err)
+		return nil, err
+	}
 	engine.Logger.Debug("Initialized JS runtime pool",
-		"runtime", jsruntime.DefaultRuntimeType(),
-		"pool_size", config.JSRuntimePoolSize)
+		"runtime", engine.RuntimePool.RuntimeType(),
+		"pool_size", config.JSRuntimePoolSize,
+		"min_pool_size", engine.RuntimePool.Stats()["min_pool_size"])
 	utils.CleanCacheDirectories()
 	// If using a layout css file, build it and cache it
 	if config.LayoutCSSFilePath != "" {
@@ -72,6 +114,9 @@ func New(config Config) (*Engine, error) {
 		}
 	}
 
+	// Client bundles, including the SPA bundle built below, need to know whether to include React Refresh
+	engine.hmrEnabled = engine.reactRefreshEnabled()
+
 	// If using client SPA app, build bundles based on SPAHydrationMode
 	if config.ClientAppPath != "" {
 		if config.SPAHydrationMode == "router" {
@@ -93,6 +138,15 @@ func New(config Config) (*Engine, error) {
 		return nil, err
 	}
 
+	if len(config.WarmupFiles) > 0 {
+		if err := engine.Warmup(context.Background(), config.WarmupFiles...); err != nil {
+			engine.Logger.Error("Failed to warm up", "error", err)
+			// Release the runtime pool and stop the hot reload server so the port can be bound again
+			engine.Shutdown(context.Background())
+			return nil, err
+		}
+	}
+
 	return engine, nil
 }
 
@@ -102,6 +156,12 @@ func New(config Config) (*Engine, error) {
 func (engine *Engine) Shutdown(ctx context.Context) error {
 	engine.Logger.Info("Shutting down gotossr engine")
 
+	// Stop hot reload server and file watcher first so file changes no longer trigger builds (dev only)
+	hotReloadErr := engine.stopHotReload(ctx)
+	if hotReloadErr != nil {
+		engine.Logger.Error("Failed to stop hot reload", "error", hotReloadErr)
+	}
+
 	// Close the runtime pool
 	if engine.RuntimePool != nil {
 		engine.RuntimePool.Close()
@@ -116,11 +176,56 @@ func (engine *Engine) Shutdown(ctx context.Context) error {
 		engine.Logger.Debug("Cache cleared")
 	}
 
-	// Stop hot reload server (dev only)
-	engine.stopHotReload()
-
 	engine.Logger.Info("gotossr engine shutdown complete")
-	return nil
+	return hotReloadErr
+}
+
+// persistentCodeCache returns where the code compiled by the JS runtimes is persisted, nil to keep it in memory
+func (engine *Engine) persistentCodeCache() (jsruntime.CodeCache, error) {
+	switch engine.Config.JSRuntimeCodeCache {
+	case "disk":
+		dir := engine.Config.JSRuntimeCodeCacheDir
+		if dir == "" {
+			var err error
+			if dir, err = utils.GetCodeCacheDir(); err != nil {
+				return nil, err
+			}
+		}
+		codeCache, err := jsruntime.NewDirCodeCache(dir)
+		if err != nil {
+			return nil, err
+		}
+		return loggedCodeCache{codeCache, engine.Logger}, nil
+	case "cache":
+		// The pool keeps the code in memory already, a local cache would only hold a second copy
+		if _, ok := engine.Cache.(*cache.LocalCache); ok {
+			return nil, nil
+		}
+		return loggedCodeCache{engine.Cache, engine.Logger}, nil
+	}
+	return nil, nil
+}
+
+// loggedCodeCache logs the errors of a code cache, which the runtimes ignore by compiling again
+type loggedCodeCache struct {
+	jsruntime.CodeCache
+	logger *slog.Logger
+}
+
+func (c loggedCodeCache) GetCodeCache(key string) ([]byte, bool, error) {
+	data, ok, err := c.CodeCache.GetCodeCache(key)
+	if err != nil {
+		c.logger.Warn("Failed to read compiled code from the code cache", "key", key, "error", err)
+	}
+	return data, ok, err
+}
+
+func (c loggedCodeCache) SetCodeCache(key string, data []byte) error {
+	err := c.CodeCache.SetCodeCache(key, data)
+	if err != nil {
+		c.logger.Warn("Failed to write compiled code to the code cache", "key", key, "error", err)
+	}
+	return err
 }
 
 // buildServerSPAApp builds the server SPA app bundle (with StaticRouter for "router" mode)
@@ -139,13 +244,14 @@ func (engine *Engine) buildServerSPAApp() error {
 		return nil
 	}
 
-	result, err := reactbuilder.BuildServer(buildContents, engine.Config.FrontendDir, engine.Config.AssetRoute)
+	result, err := reactbuilder.BuildServer(buildContents, engine.Config.FrontendDir, engine.Config.AssetRoute, engine.RuntimePool.ESTarget())
 	if err != nil {
 		return err
 	}
 
 	engine.CachedServerSPAJS = result.JS
 	engine.CachedServerSPACSS = result.CSS
+	engine.CachedServerSPASourceMap = result.SourceMap
 	// Debug: show last 500 chars of generated JS
 	jsLen := len(result.JS)
 	lastPart := result.JS
@@ -170,7 +276,7 @@ func (engine *Engine) buildClientSPAApp() error {
 		return err
 	}
 
-	result, err := reactbuilder.BuildClient(buildContents, engine.Config.FrontendDir, engine.Config.AssetRoute, engine.IsProduction())
+	result, err := reactbuilder.BuildClient(buildContents, engine.Config.FrontendDir, engine.Config.AssetRoute, engine.IsProduction(), engine.clientExternals(), engine.hmrEnabled)
 	if err != nil {
 		return err
 	}
@@ -179,3 +285,34 @@ func (engine *Engine) buildClientSPAApp() error {
 	engine.Logger.Debug("Built client SPA app", "path", engine.Config.ClientAppPath, "mode", engine.Config.SPAHydrationMode)
 	return nil
 }
+
+// clientExternals returns the packages that are loaded through the import map instead of bundled
+func (engine *Engine) clientExternals() []string {
+	if len(engine.Config.ImportMap) == 0 {
+		return nil
+	}
+	externals := make([]string, 0, len(engine.Config.ImportMap))
+	for specifier := range engine.Config.ImportMap {
+		// Trailing-slash prefixes cover subpaths in import maps, esbuild needs a wildcard for them
+		if strings.HasSuffix(specifier, "/") {
+			specifier += "*"
+		}
+		externals = append(externals, specifier)
+	}
+	sort.Strings(externals)
+	return externals
+}
+
+// importMapTag returns the <script type="importmap"> tag for the configured import map
+func (engine *Engine) importMapTag() template.HTML {
+	if len(engine.Config.ImportMap) == 0 {
+		return ""
+	}
+	// json.Marshal escapes <, > and & so the map can't break out of the script tag
+	importMap, err := json.Marshal(map[string]map[string]string{"imports": engine.Config.ImportMap})
+	if err != nil {
+		engine.Logger.Error("Failed to marshal import map", "error", err)
+		return ""
+	}
+	return template.HTML(`<script type="importmap">` + string(importMap) + `</script>`)
+}
@@ -3,8 +3,16 @@
 package go_ssr
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"os"
+	"reflect"
+	"strings"
+	"sync"
 
+	"github.com/yejune/gotossr/internal/html"
+	"github.com/yejune/gotossr/internal/reactbuilder"
 	"github.com/yejune/gotossr/internal/typeconverter"
 )
 
@@ -20,7 +28,7 @@ func (engine *Engine) initDevTools() error {
 	engine.Logger.Debug("Starting type converter")
 
 	// Start the type converter to convert Go types to Typescript types
-	if err := typeconverter.Start(engine.Config.PropsStructsPath, engine.Config.GeneratedTypesPath); err != nil {
+	if err := engine.generateTypes(); err != nil {
 		engine.Logger.Error("Failed to init type converter", "error", err)
 		return err
 	}
@@ -38,15 +46,161 @@ func (engine *Engine) initDevTools() error {
 
 	engine.Logger.Debug("Starting hot reload server")
 	engine.HotReload = newHotReload(engine)
-	engine.HotReload.Start()
+	if err := engine.HotReload.Start(); err != nil {
+		engine.Logger.Error("Failed to start hot reload", "error", err)
+		return err
+	}
 
 	return nil
 }
 
-// stopHotReload stops the hot reload server (dev only)
-func (engine *Engine) stopHotReload() {
+// reactRefreshEnabled reports whether client bundles are built with React Refresh to hot update components in place,
+// which requires react-refresh to be installed. It must be known before the first client bundle is built.
+func (engine *Engine) reactRefreshEnabled() bool {
+	if os.Getenv("APP_ENV") == "production" {
+		return false
+	}
+	if !reactbuilder.ReactRefreshAvailable(engine.Config.FrontendDir) {
+		engine.Logger.Debug("react-refresh is not installed, pages reload on every change")
+		return false
+	}
+	engine.Logger.Debug("React Fast Refresh enabled")
+	return true
+}
+
+// hotReloadEnabled reports whether bundles are rebuilt when their files change, which is the case in development
+func (engine *Engine) hotReloadEnabled() bool {
+	return os.Getenv("APP_ENV") != "production"
+}
+
+// stopHotReload stops the hot reload server and file watcher and closes client connections (dev only)
+func (engine *Engine) stopHotReload(ctx context.Context) error {
+	if engine.HotReload == nil {
+		return nil
+	}
+	engine.Logger.Debug("Hot reload server stopping")
+	return engine.HotReload.Stop(ctx)
+}
+
+// HotReloadHandler returns the hot reload websocket endpoint, to be mounted at Config.HotReloadPath
+// on the app's router when Config.HotReloadEmbedded is set. It responds with 404 when hot reload is off.
+func (engine *Engine) HotReloadHandler() http.Handler {
+	if engine.HotReload == nil {
+		return http.NotFoundHandler()
+	}
+	return engine.HotReload
+}
+
+// hotReloadParams returns where the dev client connects to the hot reload websocket and the token it authenticates with
+func (engine *Engine) hotReloadParams() html.HotReloadParams {
+	if engine.HotReload == nil {
+		return html.HotReloadParams{}
+	}
+	params := html.HotReloadParams{Path: engine.Config.HotReloadPath, Token: engine.HotReload.token}
+	if !engine.Config.HotReloadEmbedded {
+		params.Port = engine.Config.HotReloadServerPort
+	}
+	return params
+}
+
+// generateTypes converts the props structs to TypeScript and keeps their schema to validate rendered props against
+// The request types are converted to Go structs
+func (engine *Engine) generateTypes() error {
+	schema, err := typeconverter.Start(typeconverter.Config{
+		StructsFilePath:    engine.Config.PropsStructsPath,
+		GeneratedTypesPath: engine.Config.GeneratedTypesPath,
+		SchemaPath:         engine.Config.PropsSchemaPath,
+		ZodPath:            engine.Config.PropsZodPath,
+		TypeMappings:       engine.Config.TypeMappings,
+		Routes:             engine.registeredPages(),
+
+		TypeScriptFilesPath: engine.Config.RequestTypesPath,
+		GoStructsPath:       engine.Config.GeneratedStructsPath,
+	})
+	if err != nil {
+		return err
+	}
+	if schema != nil {
+		engine.propsSchema.Store(schema)
+	}
+	return nil
+}
+
+// CheckTypes generates the props types and schemas in memory and compares them with the files of config
+// It returns an error wrapping ErrTypesOutdated with a unified diff when they differ, e.g. to fail CI:
+//
+//	if err := gossr.CheckTypes(config); err != nil {
+//		t.Fatal(err)
+//	}
+func CheckTypes(config Config) error {
+	diff, err := typeconverter.Check(typeconverter.Config{
+		StructsFilePath:    config.PropsStructsPath,
+		GeneratedTypesPath: config.GeneratedTypesPath,
+		SchemaPath:         config.PropsSchemaPath,
+		ZodPath:            config.PropsZodPath,
+		TypeMappings:       config.TypeMappings,
+
+		TypeScriptFilesPath: config.RequestTypesPath,
+		GoStructsPath:       config.GeneratedStructsPath,
+	})
+	if err != nil {
+		return err
+	}
+	if diff != "" {
+		return fmt.Errorf("%w, regenerate them with the dev server or gossr-types:\n%s", ErrTypesOutdated, diff)
+	}
+	return nil
+}
+
+// pagesChanged regenerates the types to add the Routes of newly declared pages
+func (engine *Engine) pagesChanged() {
 	if engine.HotReload != nil {
-		engine.Logger.Debug("Hot reload server stopping")
-		// Hot reload server runs in a goroutine; it will be cleaned up on process exit
+		engine.HotReload.schedulePagesTypes()
+	}
+}
+
+// globalLeakWarning returns the handler warning about the globals assigned by renders, once per global
+// They are seen by the next renders on the same JS runtime, so it is only set without isolation.
+func (engine *Engine) globalLeakWarning() func(names []string) {
+	if engine.IsProduction() || engine.Config.JSRuntimeIsolation != "" {
+		return nil
+	}
+	var warned sync.Map
+	return func(names []string) {
+		var leaked []string
+		for _, name := range names {
+			if _, loaded := warned.LoadOrStore(name, true); !loaded {
+				leaked = append(leaked, name)
+			}
+		}
+		if len(leaked) > 0 {
+			engine.Logger.Warn("Render assigned globals, which leak into the next renders on the same JS runtime",
+				"globals", strings.Join(leaked, ", "),
+				"hint", `keep request state out of globalThis or set JSRuntimeIsolation to "globals" or "context"`)
+		}
+	}
+}
+
+// validateProps warns when the marshaled props don't match the schema of their struct,
+// e.g. when the struct changed since the server was started and the frontend types are out of date
+func (engine *Engine) validateProps(file string, props any, propsJSON string) {
+	schema, _ := engine.propsSchema.Load().(*typeconverter.Schema)
+	if schema == nil || props == nil {
+		return
+	}
+	propsType := reflect.TypeOf(props)
+	for propsType.Kind() == reflect.Pointer {
+		propsType = propsType.Elem()
+	}
+	if schema.Def(propsType.Name()) == nil {
+		return
+	}
+	problems, err := schema.Validate(propsType.Name(), []byte(propsJSON))
+	if err != nil {
+		engine.Logger.Debug("Failed to validate props", "file", file, "error", err)
+		return
+	}
+	if len(problems) > 0 {
+		engine.Logger.Warn("Props don't match the generated types", "file", file, "type", propsType.Name(), "problems", strings.Join(problems, "; "))
 	}
 }
@@ -2,13 +2,58 @@
 
 package go_ssr
 
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/yejune/gotossr/internal/html"
+)
+
 // initDevTools is a no-op in production builds
 func (engine *Engine) initDevTools() error {
 	engine.Logger.Info("Running go-ssr in production mode")
 	return nil
 }
 
+// reactRefreshEnabled is false in production builds
+func (engine *Engine) reactRefreshEnabled() bool {
+	return false
+}
+
+// hotReloadEnabled is false in production builds
+func (engine *Engine) hotReloadEnabled() bool {
+	return false
+}
+
+// CheckTypes is not available in production builds, types are only generated in development
+func CheckTypes(config Config) error {
+	return errors.New("gossr: CheckTypes is not available in production builds")
+}
+
+// pagesChanged is a no-op in production builds, types are only generated in development
+func (engine *Engine) pagesChanged() {}
+
+// globalLeakWarning is nil in production builds, globals aren't tracked without isolation
+func (engine *Engine) globalLeakWarning() func(names []string) {
+	return nil
+}
+
+// validateProps is a no-op in production builds
+func (engine *Engine) validateProps(file string, props any, propsJSON string) {}
+
 // stopHotReload is a no-op in production builds
-func (engine *Engine) stopHotReload() {
+func (engine *Engine) stopHotReload(ctx context.Context) error {
 	// No hot reload in production
+	return nil
+}
+
+// HotReloadHandler responds with 404 in production builds
+func (engine *Engine) HotReloadHandler() http.Handler {
+	return http.NotFoundHandler()
+}
+
+// hotReloadParams is empty in production builds
+func (engine *Engine) hotReloadParams() html.HotReloadParams {
+	return html.HotReloadParams{}
 }
@@ -1,8 +1,15 @@
 package go_ssr
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/yejune/gotossr/internal/cache"
+	"github.com/yejune/gotossr/internal/jsruntime"
+	"github.com/yejune/gotossr/internal/reactbuilder"
 	"net"
 	"os"
 	"testing"
@@ -22,8 +29,9 @@ func TestNew_Dev(t *testing.T) {
 	assert.Nil(t, err, "ReadFile should not return an error")
 	err = os.Truncate(config.GeneratedTypesPath, 0)
 	assert.Nil(t, err, "os.Truncate should not return an error, got %v", err)
-	_, err = New(config)
+	engine, err := New(config)
 	assert.Nil(t, err, "gossr.New should not return an error, got %v", err)
+	defer engine.Shutdown(context.Background())
 
 	contents, err := os.ReadFile(config.GeneratedTypesPath)
 	assert.Nil(t, err, "ReadFile should not return an error")
@@ -43,6 +51,27 @@ func TestNew_Dev(t *testing.T) {
 	err = os.WriteFile(config.GeneratedTypesPath, originalContents, 0644)
 }
 
+func TestEngine_Shutdown(t *testing.T) {
+	config := Config{
+		AppEnv:              "development",
+		FrontendDir:         "./examples/frontend/src",
+		HotReloadServerPort: 4002,
+	}
+
+	// Recreating the engine only works if the hot reload server released its port
+	for i := 0; i < 2; i++ {
+		engine, err := New(config)
+		assert.Nil(t, err, "gossr.New should not return an error, got %v", err)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = engine.Shutdown(ctx)
+		cancel()
+		assert.Nil(t, err, "Shutdown should not return an error, got %v", err)
+	}
+
+	conn, _ := net.DialTimeout("tcp", net.JoinHostPort("", fmt.Sprintf("%d", config.HotReloadServerPort)), time.Second)
+	assert.Nil(t, conn, "Hot reload server should be stopped")
+}
+
 func TestNew_Prod(t *testing.T) {
 	config := Config{
 		AppEnv:             "production",
@@ -64,3 +93,96 @@ func TestNew_Prod(t *testing.T) {
 
 	err = os.WriteFile(config.GeneratedTypesPath, originalContents, 0644)
 }
+
+func TestNew_UnavailableRuntime(t *testing.T) {
+	config := Config{
+		AppEnv:      "production",
+		FrontendDir: "./examples/frontend/src",
+		JSRuntime:   "rhino",
+	}
+
+	_, err := New(config)
+	assert.ErrorIs(t, err, jsruntime.ErrRuntimeNotAvailable, "gossr.New should fail when the runtime isn't compiled in")
+}
+
+func TestRenderRoute_Runtimes(t *testing.T) {
+	// testdata/frontend stands in for React, so it runs without installing the dependencies of the examples.
+	// CI installs them and compares the runtimes on real React too.
+	for _, frontend := range []string{"./testdata/frontend", "./examples/frontend", "./examples/frontend-bootstrap", "./examples/frontend-mui", "./examples/frontend-tailwind"} {
+		t.Run(frontend, func(t *testing.T) {
+			if _, err := os.Stat(frontend + "/node_modules"); err != nil {
+				t.Skip("Dependencies of the example aren't installed, run npm install in " + frontend)
+			}
+
+			// Every runtime compiled in should render the same HTML
+			rendered := map[jsruntime.RuntimeType]string{}
+			for _, runtimeType := range jsruntime.AvailableRuntimes() {
+				engine, err := New(Config{
+					AppEnv:      "production",
+					FrontendDir: frontend + "/src",
+					JSRuntime:   string(runtimeType),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				rendered[runtimeType] = string(engine.RenderRoute(RenderConfig{
+					File:  "Home.tsx",
+					Props: &IndexRouteProps{InitialCount: 42},
+				}))
+				engine.Shutdown(context.Background())
+
+				assert.NotContains(t, rendered[runtimeType], "<title>An error occured!</title>", "%s should render the page", runtimeType)
+				assert.Contains(t, rendered[runtimeType], "42", "%s should render the props", runtimeType)
+				assert.NotContains(t, rendered[runtimeType], `<div id="root">undefined</div>`, "%s should return the rendered HTML", runtimeType)
+			}
+			for runtimeType, html := range rendered {
+				assert.Equal(t, rendered[jsruntime.DefaultRuntimeType()], html, "%s should render the same HTML as %s", runtimeType, jsruntime.DefaultRuntimeType())
+			}
+		})
+	}
+}
+
+func TestEngine_ClientExternals(t *testing.T) {
+	engine := &Engine{Config: &Config{ImportMap: map[string]string{
+		"react":      "https://cdn.example.com/react.mjs",
+		"react-dom/": "https://cdn.example.com/react-dom/",
+	}}}
+	assert.Equal(t, []string{"react", "react-dom/*"}, engine.clientExternals(), "Trailing-slash prefixes should become wildcards")
+
+	// Neither import can be resolved from the temp dir, so the build only succeeds if both are external
+	result, err := reactbuilder.BuildClient(`import React from "react"; import { createRoot } from "react-dom/client"; console.log(React, createRoot);`,
+		t.TempDir(), "/assets", false, engine.clientExternals(), false)
+	assert.Nil(t, err, "BuildClient should not return an error")
+	assert.Contains(t, result.JS, `from "react-dom/client"`, "Subpath imports of prefixes should be left to the import map")
+}
+
+func TestEngine_PersistentCodeCache(t *testing.T) {
+	engine := &Engine{Config: &Config{JSRuntimeCodeCache: "cache"}, Cache: cache.NewLocalCache()}
+	codeCache, err := engine.persistentCodeCache()
+	assert.Nil(t, err, "persistentCodeCache should not return an error")
+	assert.Nil(t, codeCache, "The local cache shouldn't hold a second copy of the code kept in memory")
+
+	engine.Config = &Config{JSRuntimeCodeCache: "disk", JSRuntimeCodeCacheDir: t.TempDir()}
+	codeCache, err = engine.persistentCodeCache()
+	assert.Nil(t, err, "persistentCodeCache should not return an error")
+	assert.NotNil(t, codeCache, "The disk code cache should persist the code")
+}
+
+func TestEngine_Shutdown_KeepsCodeCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisCache, err := cache.NewCache(cache.CacheConfig{Type: cache.CacheTypeRedis, RedisAddr: server.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := &Engine{Logger: slog.Default(), Config: &Config{JSRuntimeCodeCache: "cache"}, Cache: redisCache}
+	assert.NoError(t, redisCache.SetServerBuild("Home.tsx", reactbuilder.BuildResult{JS: "js"}))
+	assert.NoError(t, redisCache.SetCodeCache("v8-1.0-abc", []byte("code")))
+
+	assert.NoError(t, engine.Shutdown(context.Background()))
+	_, ok, _ := redisCache.GetServerBuild("Home.tsx")
+	assert.False(t, ok, "Builds should be cleared on shutdown")
+	data, ok, err := redisCache.GetCodeCache("v8-1.0-abc")
+	assert.NoError(t, err)
+	assert.True(t, ok, "Compiled code should survive shutdown so the next servers skip compilation")
+	assert.Equal(t, []byte("code"), data)
+}
@@ -0,0 +1,122 @@
+package go_ssr
+
+import (
+	"errors"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yejune/gotossr/internal/html"
+	"github.com/yejune/gotossr/internal/reactbuilder"
+)
+
+// codeFrameContextLines is the number of lines shown above and below the error line
+const codeFrameContextLines = 3
+
+// stackFrameRegex matches a source position in a (remapped) JS stack frame,
+// e.g. "    at Counter (/app/frontend/src/Counter.tsx:12:9)"
+var stackFrameRegex = regexp.MustCompile(`^\s*at (?:(\S+) \()?(/[^()]+?):(\d+):(\d+)\)?\s*$`)
+
+// renderErrorPage renders the error page for a failed route
+// In development this is an overlay with the error location, a code frame and the component stack.
+// In production it's a generic message that never includes paths.
+func (engine *Engine) renderErrorPage(err error, routeID string) []byte {
+	params := html.ErrorParams{
+		Title:     "Render error",
+		Error:     err.Error(),
+		RouteID:   routeID,
+		HotReload: engine.hotReloadParams(),
+	}
+	if engine.IsProduction() {
+		return html.RenderErrorPage(params)
+	}
+
+	var buildErr *reactbuilder.BuildError
+	if errors.As(err, &buildErr) {
+		params.Title = "Build failed"
+		params.Error = buildErr.Text
+		params.File, params.Line, params.Column = buildErr.File, buildErr.Line, buildErr.Column
+	} else {
+		params.Error, params.Stack = splitJSError(err.Error())
+		frames := parseStackFrames(params.Stack)
+		for _, frame := range frames {
+			// The first frame in the app's own code is the most useful location
+			if params.File == "" && !isNodeModule(frame.file) {
+				params.File, params.Line, params.Column = frame.file, frame.line, frame.column
+			}
+			if frame.function != "" && !isNodeModule(frame.file) {
+				params.ComponentStack = append(params.ComponentStack, "at "+frame.function+" ("+engine.displayPath(frame.file)+":"+strconv.Itoa(frame.line)+")")
+			}
+		}
+	}
+
+	if params.File != "" {
+		params.DisplayFile = engine.displayPath(params.File)
+		params.EditorURL = engine.editorURL(params.File, params.Line, params.Column)
+		if source, readErr := os.ReadFile(params.File); readErr == nil {
+			params.CodeFrame = html.BuildCodeFrame(string(source), params.Line, params.Column, codeFrameContextLines)
+		}
+	}
+	return html.RenderErrorPage(params)
+}
+
+// stackFrame is a parsed JS stack frame
+type stackFrame struct {
+	function string
+	file     string
+	line     int
+	column   int
+}
+
+// splitJSError splits a JS error into its message and stack trace
+func splitJSError(message string) (string, string) {
+	lines := strings.Split(message, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "at ") {
+			return strings.TrimSpace(strings.Join(lines[:i], "\n")), strings.Join(lines[i:], "\n")
+		}
+	}
+	return message, ""
+}
+
+// parseStackFrames returns the frames of a stack trace that point to a file
+func parseStackFrames(stack string) []stackFrame {
+	var frames []stackFrame
+	for _, line := range strings.Split(stack, "\n") {
+		match := stackFrameRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		lineNum, _ := strconv.Atoi(match[3])
+		column, _ := strconv.Atoi(match[4])
+		frames = append(frames, stackFrame{function: match[1], file: match[2], line: lineNum, column: column})
+	}
+	return frames
+}
+
+func isNodeModule(file string) bool {
+	return strings.Contains(file, "/node_modules/")
+}
+
+// displayPath returns file relative to the frontend dir when it lives inside it
+func (engine *Engine) displayPath(file string) string {
+	if rel, err := filepath.Rel(engine.Config.FrontendDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+// editorURL fills the configured editor url template with the error location
+func (engine *Engine) editorURL(file string, line, column int) template.URL {
+	if engine.Config.EditorURL == "" {
+		return ""
+	}
+	return template.URL(strings.NewReplacer(
+		"{file}", file,
+		"{line}", strconv.Itoa(line),
+		"{column}", strconv.Itoa(column),
+	).Replace(engine.Config.EditorURL))
+}
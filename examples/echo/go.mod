@@ -24,8 +24,6 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/redis/go-redis/v9 v9.17.1 // indirect
 	github.com/rogpeppe/go-internal v1.6.1 // indirect
-	github.com/tkrajina/go-reflector v0.5.8 // indirect
-	github.com/tkrajina/typescriptify-golang-structs v0.2.0 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasttemplate v1.2.2 // indirect
 	golang.org/x/crypto v0.11.0 // indirect
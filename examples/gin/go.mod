@@ -35,8 +35,6 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/redis/go-redis/v9 v9.17.1 // indirect
 	github.com/rogpeppe/go-internal v1.6.1 // indirect
-	github.com/tkrajina/go-reflector v0.5.8 // indirect
-	github.com/tkrajina/typescriptify-golang-structs v0.2.0 // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.2.11 // indirect
 	golang.org/x/arch v0.3.0 // indirect
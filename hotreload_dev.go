@@ -3,160 +3,560 @@
 package go_ssr
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"maps"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/gorilla/websocket"
+	"github.com/yejune/gotossr/internal/reactbuilder"
 	"github.com/yejune/gotossr/internal/utils"
 )
 
+const (
+	hotReloadReadLimit        = 1024             // Clients only send their route ID
+	hotReloadHandshakeTimeout = 10 * time.Second // Time for a client to send its route ID after connecting
+	hotReloadWriteTimeout     = 5 * time.Second  // Time for a client to take a message before it's dropped
+)
+
+// hotReloadMessage is a message pushed to the browser over the hot reload websocket
+type hotReloadMessage struct {
+	Type string `json:"type"`           // "connected", "reload", "hmr-update", "css-update" or "types-updated"
+	File string `json:"file,omitempty"` // Changed file for "hmr-update", regenerated file for "types-updated"
+	Code string `json:"code,omitempty"` // Hot update bundle for "hmr-update"
+	CSS  string `json:"css,omitempty"`  // New page stylesheet for "css-update"
+}
+
 type HotReload struct {
 	engine           *Engine
 	logger           *slog.Logger
+	upgrader         websocket.Upgrader
+	token            string // Random per-process token that clients must send, embedded in dev pages
+	server           *http.Server
+	listener         net.Listener
+	watcher          *fsnotify.Watcher
+	pagesTimer       *time.Timer     // Regenerates the types after pages are declared
+	ctx              context.Context // Canceled on Stop, which closes client connections and the watcher loop
+	cancel           context.CancelFunc
+	handlers         sync.WaitGroup // Running websocket handlers
 	connectedClients map[string][]*websocket.Conn
+	connections      int // Number of open websocket connections, registered or not
 	mu               sync.Mutex
+	writeMu          sync.Mutex // Serializes broadcasts, a websocket connection allows a single writer
+	typesMu          sync.Mutex // Serializes type regeneration
 }
 
 // newHotReload creates a new HotReload instance
 func newHotReload(engine *Engine) *HotReload {
-	return &HotReload{
+	ctx, cancel := context.WithCancel(context.Background())
+	hr := &HotReload{
 		engine:           engine,
 		logger:           engine.Logger,
+		token:            newHotReloadToken(),
+		ctx:              ctx,
+		cancel:           cancel,
 		connectedClients: make(map[string][]*websocket.Conn),
 	}
+	hr.upgrader = websocket.Upgrader{CheckOrigin: hr.checkOrigin}
+	return hr
 }
 
-// Start starts the hot reload server and watcher
-func (hr *HotReload) Start() {
-	go hr.startServer()
-	go hr.startWatcher()
+// newHotReloadToken generates a random token for authenticating hot reload clients
+func newHotReloadToken() string {
+	token := make([]byte, 32)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(token)
+	return hex.EncodeToString(token)
 }
 
-// startServer starts the hot reload websocket server
-func (hr *HotReload) startServer() {
-	hr.logger.Info("Hot reload websocket running", "port", hr.engine.Config.HotReloadServerPort)
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			return true
-		},
+// Start starts the hot reload server and watcher
+// The standalone server is skipped when the endpoint is mounted on the app's own router.
+func (hr *HotReload) Start() error {
+	watcher, err := hr.newWatcher()
+	if err != nil {
+		return err
 	}
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		ws, err := upgrader.Upgrade(w, r, nil)
+	hr.watcher = watcher
+	if !hr.engine.Config.HotReloadEmbedded {
+		addr := net.JoinHostPort(hr.engine.Config.HotReloadHost, strconv.Itoa(hr.engine.Config.HotReloadServerPort))
+		listener, err := net.Listen("tcp", addr)
 		if err != nil {
-			hr.logger.Error("Failed to upgrade websocket", "error", err)
-			return
+			watcher.Close()
+			return fmt.Errorf("failed to start hot reload server: %w", err)
 		}
-		// Client should send routeID as first message
-		_, routeID, err := ws.ReadMessage()
-		if err != nil {
-			hr.logger.Error("Failed to read message from websocket", "error", err)
-			return
-		}
-		err = ws.WriteMessage(1, []byte("Connected"))
-		if err != nil {
-			hr.logger.Error("Failed to write message to websocket", "error", err)
-			return
-		}
-		// Add client to connectedClients
+		mux := http.NewServeMux()
+		mux.Handle(hr.engine.Config.HotReloadPath, hr)
+		hr.server = &http.Server{Handler: mux}
+		hr.listener = listener
+		hr.logger.Info("Hot reload websocket running", "addr", addr, "path", hr.engine.Config.HotReloadPath)
+		go hr.serve()
+	}
+	go hr.watch()
+	return nil
+}
+
+// serve runs the standalone hot reload websocket server until it's stopped
+func (hr *HotReload) serve() {
+	if err := hr.server.Serve(hr.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		hr.logger.Error("Hot reload server quit unexpectedly", "error", err)
+	}
+}
+
+// Stop stops the server and watcher and closes all client connections.
+// It waits for the connection handlers to exit until ctx is done.
+func (hr *HotReload) Stop(ctx context.Context) error {
+	hr.mu.Lock()
+	hr.cancel()
+	if hr.pagesTimer != nil {
+		hr.pagesTimer.Stop()
+	}
+	hr.mu.Unlock()
+
+	var errs []error
+	if hr.server != nil {
+		errs = append(errs, hr.server.Shutdown(ctx))
+		// Shutdown only closes the listener if Serve is already running
+		hr.listener.Close()
+	}
+	if hr.watcher != nil {
+		errs = append(errs, hr.watcher.Close())
+	}
+	handlersDone := make(chan struct{})
+	go func() {
+		hr.handlers.Wait()
+		close(handlersDone)
+	}()
+	select {
+	case <-handlersDone:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+	return errors.Join(errs...)
+}
+
+// ServeHTTP upgrades the request to a hot reload websocket and registers the client for its route
+// Connections need the page's token and an allowed origin, and are capped at HotReloadMaxConnections.
+func (hr *HotReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(hr.token)) != 1 {
+		hr.logger.Warn("Rejected hot reload connection with an invalid token", "remote", r.RemoteAddr)
+		http.Error(w, "invalid hot reload token", http.StatusUnauthorized)
+		return
+	}
+	hr.mu.Lock()
+	if hr.ctx.Err() != nil {
+		hr.mu.Unlock()
+		http.Error(w, "hot reload server stopped", http.StatusServiceUnavailable)
+		return
+	}
+	if hr.connections >= hr.engine.Config.HotReloadMaxConnections {
+		hr.mu.Unlock()
+		hr.logger.Warn("Rejected hot reload connection, too many connections", "remote", r.RemoteAddr)
+		http.Error(w, "too many hot reload connections", http.StatusServiceUnavailable)
+		return
+	}
+	hr.connections++
+	hr.handlers.Add(1)
+	hr.mu.Unlock()
+	defer func() {
 		hr.mu.Lock()
-		hr.connectedClients[string(routeID)] = append(hr.connectedClients[string(routeID)], ws)
+		hr.connections--
 		hr.mu.Unlock()
-	})
-	err := http.ListenAndServe(fmt.Sprintf(":%d", hr.engine.Config.HotReloadServerPort), nil)
+		hr.handlers.Done()
+	}()
+
+	ws, err := hr.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		hr.logger.Error("Hot reload server quit unexpectedly", "error", err)
+		hr.logger.Error("Failed to upgrade websocket", "error", err)
+		return
 	}
+	defer ws.Close()
+	// Closing the connection on Stop unblocks the reads below
+	stopClose := context.AfterFunc(hr.ctx, func() { ws.Close() })
+	defer stopClose()
+	// Client should send routeID as first message
+	ws.SetReadLimit(hotReloadReadLimit)
+	ws.SetReadDeadline(time.Now().Add(hotReloadHandshakeTimeout))
+	_, routeID, err := ws.ReadMessage()
+	if err != nil {
+		hr.logger.Error("Failed to read message from websocket", "error", err)
+		return
+	}
+	ws.SetReadDeadline(time.Time{})
+	err = ws.WriteJSON(hotReloadMessage{Type: "connected"})
+	if err != nil {
+		hr.logger.Error("Failed to write message to websocket", "error", err)
+		return
+	}
+	// Add client to connectedClients
+	hr.mu.Lock()
+	hr.connectedClients[string(routeID)] = append(hr.connectedClients[string(routeID)], ws)
+	hr.mu.Unlock()
+	// Keep reading until the client disconnects so that its slot is released
+	for {
+		if _, _, err := ws.ReadMessage(); err != nil {
+			break
+		}
+	}
+	hr.removeClient(string(routeID), ws)
 }
 
-// startWatcher starts the file watcher
-func (hr *HotReload) startWatcher() {
+// checkOrigin allows pages served from the loopback or HotReloadHost, and the configured extra origins
+// The request's Host header isn't trusted, a DNS rebinding page controls it and would match its own origin.
+func (hr *HotReload) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// Not a browser, the token still has to match
+		return true
+	}
+	if slices.Contains(hr.engine.Config.HotReloadAllowedOrigins, origin) {
+		return true
+	}
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	// The standalone server runs on its own port, so only the host names have to match
+	hostname := strings.ToLower(originURL.Hostname())
+	if hostname == "localhost" || strings.EqualFold(hostname, hr.engine.Config.HotReloadHost) && !isUnspecifiedHost(hostname) {
+		return true
+	}
+	if ip := net.ParseIP(hostname); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	hr.logger.Warn("Rejected hot reload connection from a foreign origin", "origin", origin)
+	return false
+}
+
+// isUnspecifiedHost checks if a listen host accepts connections on all addresses, e.g. "0.0.0.0"
+func isUnspecifiedHost(host string) bool {
+	ip := net.ParseIP(host)
+	return host == "" || ip != nil && ip.IsUnspecified()
+}
+
+// removeClient removes a disconnected client
+func (hr *HotReload) removeClient(routeID string, ws *websocket.Conn) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.connectedClients[routeID] = slices.DeleteFunc(hr.connectedClients[routeID], func(client *websocket.Conn) bool {
+		return client == ws
+	})
+	if len(hr.connectedClients[routeID]) == 0 {
+		delete(hr.connectedClients, routeID)
+	}
+}
+
+// newWatcher creates the file watcher and adds the frontend directories to it
+func (hr *HotReload) newWatcher() (*fsnotify.Watcher, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		hr.logger.Error("Failed to start watcher", "error", err)
-		return
+		return nil, fmt.Errorf("failed to start watcher: %w", err)
 	}
-	defer watcher.Close()
-	// Walk through all files in the frontend directory and add them to the watcher
-	if err = filepath.Walk(hr.engine.Config.FrontendDir, func(path string, fi os.FileInfo, err error) error {
-		if fi.Mode().IsDir() {
-			return watcher.Add(path)
+	if err = hr.addDirectory(watcher, hr.engine.Config.FrontendDir, nil); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to add files in directory to watcher: %w", err)
+	}
+	// Watch the packages of the props structs so that the TypeScript types can be regenerated
+	for _, dir := range hr.propsDirs() {
+		if err = watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to add props structs directory to watcher: %w", err)
 		}
-		return nil
-	}); err != nil {
-		hr.logger.Error("Failed to add files in directory to watcher", "error", err)
-		return
 	}
+	return watcher, nil
+}
+
+// propsDirs returns the package directories of the props structs files
+func (hr *HotReload) propsDirs() []string {
+	var dirs []string
+	for _, filePath := range strings.Split(hr.engine.Config.PropsStructsPath, ",") {
+		if filePath = strings.TrimSpace(filePath); filePath != "" && !slices.Contains(dirs, filepath.Dir(filePath)) {
+			dirs = append(dirs, filepath.Dir(filePath))
+		}
+	}
+	return dirs
+}
+
+// isPropsFile checks if the file is a Go file in the package of the props structs
+func (hr *HotReload) isPropsFile(filePath string) bool {
+	return strings.HasSuffix(filePath, ".go") && slices.Contains(hr.propsDirs(), filepath.Dir(filePath))
+}
+
+// isRequestTypesFile checks if the file is one of the TypeScript files converted to Go structs
+func (hr *HotReload) isRequestTypesFile(filePath string) bool {
+	for _, requestTypesPath := range strings.Split(hr.engine.Config.RequestTypesPath, ",") {
+		if requestTypesPath = strings.TrimSpace(requestTypesPath); requestTypesPath != "" && requestTypesPath == filePath {
+			return true
+		}
+	}
+	return false
+}
+
+// addDirectory adds a directory and all its subdirectories that aren't ignored to the watcher
+// The files that aren't ignored are passed to addFile unless it's nil, e.g. for the files of a directory moved in.
+func (hr *HotReload) addDirectory(watcher *fsnotify.Watcher, dir string, addFile func(filePath string)) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			if addFile != nil && entry.Type().IsRegular() && !hr.isIgnored(path) {
+				addFile(path)
+			}
+			return nil
+		}
+		if path != hr.engine.Config.FrontendDir && hr.isIgnored(path) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// isIgnored checks if a file or directory matches one of the HotReloadIgnore patterns or is build output
+func (hr *HotReload) isIgnored(filePath string) bool {
+	if strings.Contains(filePath, "gossr-temporary") || filePath == hr.engine.Config.GeneratedTypesPath || filePath == hr.engine.Config.GeneratedStructsPath {
+		return true
+	}
+	if staticDir := hr.engine.Config.StaticJSDir; staticDir != "" && (filePath == staticDir || strings.HasPrefix(filePath, staticDir+string(filepath.Separator))) {
+		return true
+	}
+	relPath, err := filepath.Rel(hr.engine.Config.FrontendDir, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range hr.engine.Config.HotReloadIgnore {
+		// Patterns match either the name of the file or its path relative to the frontend dir
+		if matched, _ := path.Match(pattern, filepath.Base(filePath)); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return true
+		}
+	}
+	return false
+}
 
+// watch collects file changes until the watcher is stopped. Changes are handled once no
+// new events arrived for HotReloadDebounce, so that editor save bursts cause a single rebuild.
+func (hr *HotReload) watch() {
+	watcher := hr.watcher
+	changedFiles := make(map[string]struct{})
+	var debounce <-chan time.Time
 	for {
 		select {
-		case event := <-watcher.Events:
+		case <-hr.ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
 			// Watch for file created, deleted, updated, or renamed events
-			if event.Op.String() != "CHMOD" && !strings.Contains(event.Name, "gossr-temporary") {
-				filePath := utils.GetFullFilePath(event.Name)
-				hr.logger.Info("File changed, reloading", "file", filePath)
-				// Store the routes that need to be reloaded
-				var routeIDS []string
-				var cacheErr error
-				switch {
-				case filePath == hr.engine.Config.LayoutFilePath: // If the layout file has been updated, reload all routes
-					routeIDS, cacheErr = hr.engine.Cache.GetAllRouteIDS()
-					if cacheErr != nil {
-						hr.logger.Error("Failed to get all route IDs", "error", cacheErr)
-						continue
-					}
-				case hr.layoutCSSFileUpdated(filePath): // If the global css file has been updated, rebuild it and reload all routes
-					if err := hr.engine.BuildLayoutCSSFile(); err != nil {
-						hr.logger.Error("Failed to build global css file", "error", err)
-						continue
-					}
-					routeIDS, cacheErr = hr.engine.Cache.GetAllRouteIDS()
-					if cacheErr != nil {
-						hr.logger.Error("Failed to get all route IDs", "error", cacheErr)
-						continue
-					}
-				case hr.needsTailwindRecompile(filePath): // If tailwind is enabled and a React file has been updated, rebuild the global css file and reload all routes
-					if err := hr.engine.BuildLayoutCSSFile(); err != nil {
-						hr.logger.Error("Failed to build global css file", "error", err)
-						continue
-					}
-					fallthrough
-				default:
-					// Get all route ids that use that file or have it as a dependency
-					routeIDS, cacheErr = hr.engine.Cache.GetRouteIDSWithFile(filePath)
-					if cacheErr != nil {
-						hr.logger.Error("Failed to get route IDs with file", "error", cacheErr)
-						continue
-					}
-				}
-				// Find any parent files that import the file that was modified and delete their cached build
-				parentFiles, cacheErr := hr.engine.Cache.GetParentFilesFromDependency(filePath)
-				if cacheErr != nil {
-					hr.logger.Error("Failed to get parent files from dependency", "error", cacheErr)
-				}
-				for _, parentFile := range parentFiles {
-					if err := hr.engine.Cache.RemoveServerBuild(parentFile); err != nil {
-						hr.logger.Error("Failed to remove server build", "error", err)
-					}
-					if err := hr.engine.Cache.RemoveClientBuild(parentFile); err != nil {
-						hr.logger.Error("Failed to remove client build", "error", err)
+			if event.Op == fsnotify.Chmod || hr.isIgnored(event.Name) {
+				continue
+			}
+			// Directories created after startup have to be watched too, and the files they already hold have changed
+			if event.Has(fsnotify.Create) {
+				if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+					err := hr.addDirectory(watcher, event.Name, func(filePath string) {
+						changedFiles[utils.GetFullFilePath(filePath)] = struct{}{}
+						debounce = time.After(hr.engine.Config.HotReloadDebounce)
+					})
+					if err != nil {
+						hr.logger.Error("Failed to add directory to watcher", "dir", event.Name, "error", err)
 					}
+					continue
 				}
-				// Reload any routes that import the modified file
-				go hr.broadcastFileUpdateToClients(routeIDS)
-
 			}
-		case err := <-watcher.Errors:
+			changedFiles[utils.GetFullFilePath(event.Name)] = struct{}{}
+			debounce = time.After(hr.engine.Config.HotReloadDebounce)
+		case <-debounce:
+			debounce = nil
+			filePaths := slices.Sorted(maps.Keys(changedFiles))
+			clear(changedFiles)
+			hr.handleFileChanges(filePaths)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
 			hr.logger.Error("Error watching files", "error", err)
 		}
 	}
 }
 
+// handleFileChanges invalidates the builds that depend on the changed files and updates
+// the affected routes, sending each client at most one reload
+func (hr *HotReload) handleFileChanges(filePaths []string) {
+	// Routes that need to be reloaded
+	reloadRouteIDS := make(map[string]struct{})
+	// React files that can be hot updated in place instead of reloading the page, with their routes
+	hotUpdates := make(map[string][]string)
+	// CSS files whose routes get the new css swapped in
+	var cssFiles []string
+	rebuildLayoutCSS := false
+	// Types are regenerated once for all the changed files
+	regenerateTypes := false
+	for _, filePath := range filePaths {
+		// Request types are regular frontend files too, the routes importing them are updated below
+		if hr.isRequestTypesFile(filePath) {
+			hr.logger.Info("Request types changed, regenerating Go structs", "file", filePath)
+			regenerateTypes = true
+		}
+		if hr.isPropsFile(filePath) {
+			hr.logger.Info("Props structs changed, regenerating types", "file", filePath)
+			regenerateTypes = true
+			continue
+		}
+		hr.logger.Info("File changed, reloading", "file", filePath)
+		var routeIDS []string
+		var cacheErr error
+		switch {
+		case filePath == hr.engine.Config.LayoutFilePath: // If the layout file has been updated, reload all routes
+			routeIDS, cacheErr = hr.engine.Cache.GetAllRouteIDS()
+			if cacheErr != nil {
+				hr.logger.Error("Failed to get all route IDs", "error", cacheErr)
+				continue
+			}
+			for _, routeID := range routeIDS {
+				reloadRouteIDS[routeID] = struct{}{}
+			}
+		case hr.layoutCSSFileUpdated(filePath): // If the global css file has been updated, rebuild it and swap the css of all routes
+			rebuildLayoutCSS = true
+			continue
+		case isCSSFile(filePath): // If a css file has been updated, swap the css of the routes that import it
+			cssFiles = append(cssFiles, filePath)
+			continue
+		default:
+			// If tailwind is enabled and a React file has been updated, rebuild the global css file and swap the css of all routes
+			if hr.needsTailwindRecompile(filePath) {
+				rebuildLayoutCSS = true
+			}
+			// Get all route ids that use that file or have it as a dependency
+			routeIDS, cacheErr = hr.engine.Cache.GetRouteIDSWithFile(filePath)
+			if cacheErr != nil {
+				hr.logger.Error("Failed to get route IDs with file", "error", cacheErr)
+				continue
+			}
+			if hr.engine.hmrEnabled && isScriptFile(filePath) {
+				hotUpdates[filePath] = routeIDS
+			} else {
+				for _, routeID := range routeIDS {
+					reloadRouteIDS[routeID] = struct{}{}
+				}
+			}
+		}
+		// Find any parent files that import the file that was modified and delete their cached build
+		parentFiles, cacheErr := hr.engine.Cache.GetParentFilesFromDependency(filePath)
+		if cacheErr != nil {
+			hr.logger.Error("Failed to get parent files from dependency", "error", cacheErr)
+		}
+		for _, parentFile := range parentFiles {
+			if err := hr.engine.Cache.RemoveServerBuild(parentFile); err != nil {
+				hr.logger.Error("Failed to remove server build", "error", err)
+			}
+			if err := hr.engine.Cache.RemoveClientBuild(parentFile); err != nil {
+				hr.logger.Error("Failed to remove client build", "error", err)
+			}
+		}
+	}
+
+	if regenerateTypes {
+		go hr.regenerateTypes()
+	}
+	if rebuildLayoutCSS {
+		if err := hr.engine.BuildLayoutCSSFile(); err != nil {
+			hr.logger.Error("Failed to build global css file", "error", err)
+		} else {
+			cssFiles = append(cssFiles, hr.engine.CachedLayoutCSSFilePath)
+		}
+	}
+	for _, cssFile := range cssFiles {
+		go hr.broadcastCSSUpdate(cssFile)
+	}
+	// Hot update the routes that import the modified React files, unless they reload anyway
+	for filePath, routeIDS := range hotUpdates {
+		routeIDS = slices.DeleteFunc(routeIDS, func(routeID string) bool {
+			_, reload := reloadRouteIDS[routeID]
+			return reload
+		})
+		if len(routeIDS) > 0 {
+			go hr.broadcastHotUpdate(filePath, routeIDS)
+		}
+	}
+	if len(reloadRouteIDS) > 0 {
+		go hr.broadcastFileUpdateToClients(slices.Collect(maps.Keys(reloadRouteIDS)))
+	}
+}
+
+// regenerateTypes regenerates the TypeScript types of the props structs and notifies all clients if they changed
+func (hr *HotReload) regenerateTypes() {
+	// Generation can take a while, don't let a second run overwrite the file at the same time
+	hr.typesMu.Lock()
+	defer hr.typesMu.Unlock()
+	oldTypes, _ := os.ReadFile(hr.engine.Config.GeneratedTypesPath)
+	oldStructs, _ := os.ReadFile(hr.engine.Config.GeneratedStructsPath)
+	if err := hr.engine.generateTypes(); err != nil {
+		hr.logger.Error("Failed to regenerate types", "error", err)
+		return
+	}
+	if hr.engine.Config.GeneratedStructsPath != "" {
+		if newStructs, _ := os.ReadFile(hr.engine.Config.GeneratedStructsPath); !bytes.Equal(oldStructs, newStructs) {
+			hr.logger.Info("Regenerated Go structs, restart the server to use them", "file", hr.engine.Config.GeneratedStructsPath)
+		}
+	}
+	newTypes, err := os.ReadFile(hr.engine.Config.GeneratedTypesPath)
+	if err != nil {
+		hr.logger.Error("Failed to read generated types", "error", err)
+		return
+	}
+	if bytes.Equal(oldTypes, newTypes) {
+		return
+	}
+	hr.logger.Info("Regenerated types", "file", hr.engine.Config.GeneratedTypesPath)
+	routeIDS, err := hr.engine.Cache.GetAllRouteIDS()
+	if err != nil {
+		hr.logger.Error("Failed to get all route IDs", "error", err)
+		return
+	}
+	hr.broadcast(routeIDS, hotReloadMessage{Type: "types-updated", File: hr.engine.displayPath(hr.engine.Config.GeneratedTypesPath)})
+}
+
+// schedulePagesTypes regenerates the types once pages stop being declared, so that their Routes are written once
+func (hr *HotReload) schedulePagesTypes() {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	if hr.ctx.Err() != nil {
+		return
+	}
+	if hr.pagesTimer != nil {
+		hr.pagesTimer.Stop()
+	}
+	hr.pagesTimer = time.AfterFunc(hr.engine.Config.HotReloadDebounce, func() {
+		if hr.ctx.Err() == nil {
+			hr.regenerateTypes()
+		}
+	})
+}
+
 // layoutCSSFileUpdated checks if the layout css file has been updated
 func (hr *HotReload) layoutCSSFileUpdated(filePath string) bool {
 	return utils.GetFullFilePath(filePath) == hr.engine.Config.LayoutCSSFilePath
@@ -167,6 +567,11 @@ func (hr *HotReload) needsTailwindRecompile(filePath string) bool {
 	if hr.engine.Config.TailwindConfigPath == "" {
 		return false
 	}
+	return isScriptFile(filePath)
+}
+
+// isScriptFile checks if the file is a JS or TS file
+func isScriptFile(filePath string) bool {
 	fileTypes := []string{".tsx", ".ts", ".jsx", ".js"}
 	for _, fileType := range fileTypes {
 		if strings.HasSuffix(filePath, fileType) {
@@ -176,22 +581,130 @@ func (hr *HotReload) needsTailwindRecompile(filePath string) bool {
 	return false
 }
 
+// isCSSFile checks if the file is a css file, including css modules
+func isCSSFile(filePath string) bool {
+	return strings.HasSuffix(filePath, ".css")
+}
+
+// broadcastCSSUpdate rebuilds the server bundles that import the css file, which the page css is taken from,
+// and sends the new css to the clients of their routes
+func (hr *HotReload) broadcastCSSUpdate(cssFilePath string) {
+	parentFiles, err := hr.engine.Cache.GetParentFilesFromDependency(cssFilePath)
+	if err != nil {
+		hr.logger.Error("Failed to get parent files from dependency", "error", err)
+		return
+	}
+	for _, parentFile := range parentFiles {
+		routeIDS, err := hr.engine.Cache.GetRouteIDSForParentFile(parentFile)
+		if err != nil {
+			hr.logger.Error("Failed to get route IDs for parent file", "error", err)
+			continue
+		}
+		oldBuild, oldBuildFound, _ := hr.engine.Cache.GetServerBuild(parentFile)
+		task := renderTask{engine: hr.engine, logger: hr.logger, filePath: parentFile}
+		build, err := task.buildFile("server")
+		if err != nil {
+			// Reloading shows the build error in the error overlay
+			hr.logger.Error("Failed to build css, reloading", "file", parentFile, "error", err)
+			if err := hr.engine.Cache.RemoveServerBuild(parentFile); err != nil {
+				hr.logger.Error("Failed to remove server build", "error", err)
+			}
+			hr.broadcastFileUpdateToClients(routeIDS)
+			continue
+		}
+		if err := hr.engine.Cache.SetServerBuild(parentFile, build); err != nil {
+			hr.logger.Error("Failed to update build cache", "error", err)
+		}
+		// Class names of css modules are part of the JS, so adding or renaming them needs a reload
+		if strings.HasSuffix(cssFilePath, ".module.css") && oldBuildFound && !slices.Equal(cssClassNames(oldBuild.CSS), cssClassNames(build.CSS)) {
+			if err := hr.engine.Cache.RemoveClientBuild(parentFile); err != nil {
+				hr.logger.Error("Failed to remove client build", "error", err)
+			}
+			hr.broadcastFileUpdateToClients(routeIDS)
+			continue
+		}
+		hr.broadcast(routeIDS, hotReloadMessage{Type: "css-update", CSS: build.CSS})
+	}
+}
+
+// cssClassNameRegex matches class selectors in built css
+var cssClassNameRegex = regexp.MustCompile(`\.(-?[_a-zA-Z][\w-]*)`)
+
+// cssClassNames returns the sorted class names used in the css
+func cssClassNames(css string) []string {
+	var classNames []string
+	for _, match := range cssClassNameRegex.FindAllStringSubmatch(css, -1) {
+		classNames = append(classNames, match[1])
+	}
+	slices.Sort(classNames)
+	return slices.Compact(classNames)
+}
+
+// broadcastHotUpdate builds a hot update bundle for the file and sends it to the clients of the routes.
+// Clients reload if the update can't be built or applied.
+func (hr *HotReload) broadcastHotUpdate(filePath string, routeIDS []string) {
+	result, err := reactbuilder.BuildHotUpdate(filePath, hr.engine.Config.FrontendDir, hr.engine.Config.AssetRoute)
+	if errors.Is(err, reactbuilder.ErrHotUpdateUnsupported) {
+		hr.logger.Info("File can't be hot updated, reloading", "file", filePath, "reason", err)
+		hr.broadcastFileUpdateToClients(routeIDS)
+		return
+	}
+	if err != nil {
+		// Reloading shows the build error in the error overlay
+		hr.logger.Error("Failed to build hot update, reloading", "file", filePath, "error", err)
+		hr.broadcastFileUpdateToClients(routeIDS)
+		return
+	}
+	hr.broadcast(routeIDS, hotReloadMessage{Type: "hmr-update", File: filePath, Code: result.JS})
+}
+
 // broadcastFileUpdateToClients sends a message to all connected clients to reload the page
 func (hr *HotReload) broadcastFileUpdateToClients(routeIDS []string) {
-	hr.mu.Lock()
-	defer hr.mu.Unlock()
+	hr.broadcast(routeIDS, hotReloadMessage{Type: "reload"})
+}
+
+// broadcast sends a message to all clients connected for the given routes
+func (hr *HotReload) broadcast(routeIDS []string, message hotReloadMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		hr.logger.Error("Failed to marshal hot reload message", "error", err)
+		return
+	}
 
-	// Iterate over each route ID
+	// Find all clients listening for the route IDs, they are written without holding hr.mu so that slow
+	// clients don't block connections and disconnections
+	clients := make(map[string][]*websocket.Conn, len(routeIDS))
+	hr.mu.Lock()
 	for _, routeID := range routeIDS {
-		// Find all clients listening for that route ID
-		var validClients []*websocket.Conn
-		for _, ws := range hr.connectedClients[routeID] {
-			// Send reload message to client
-			err := ws.WriteMessage(1, []byte("reload"))
-			if err == nil {
-				validClients = append(validClients, ws)
+		clients[routeID] = slices.Clone(hr.connectedClients[routeID])
+	}
+	hr.mu.Unlock()
+
+	hr.writeMu.Lock()
+	failed := make(map[*websocket.Conn]bool)
+	for _, routeClients := range clients {
+		for _, ws := range routeClients {
+			// Send message to client, clients that don't take it in time are dropped
+			ws.SetWriteDeadline(time.Now().Add(hotReloadWriteTimeout))
+			if err := ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				failed[ws] = true
 			}
 		}
-		hr.connectedClients[routeID] = validClients
+	}
+	hr.writeMu.Unlock()
+	if len(failed) == 0 {
+		return
+	}
+
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	for routeID := range clients {
+		hr.connectedClients[routeID] = slices.DeleteFunc(hr.connectedClients[routeID], func(ws *websocket.Conn) bool {
+			return failed[ws]
+		})
+	}
+	// Closing the connections ends their handlers
+	for ws := range failed {
+		ws.Close()
 	}
 }
@@ -0,0 +1,127 @@
+//go:build !prod
+
+package go_ssr
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHotReload_AddDirectory_Files(t *testing.T) {
+	frontendDir := t.TempDir()
+	hr := newHotReload(&Engine{Logger: slog.Default(), Config: &Config{FrontendDir: frontendDir, HotReloadIgnore: []string{"*.test.tsx", "drafts"}}})
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	// A directory moved into the frontend, whose files never get their own events
+	movedDir := filepath.Join(frontendDir, "components")
+	for _, file := range []string{"Button.tsx", "Button.test.tsx", "icons/Icon.tsx", "drafts/Draft.tsx"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(movedDir, file)), 0o755)
+		os.WriteFile(filepath.Join(movedDir, file), []byte("export {}"), 0o644)
+	}
+	var files []string
+	assert.Nil(t, hr.addDirectory(watcher, movedDir, func(filePath string) { files = append(files, filePath) }))
+	assert.Equal(t, []string{filepath.Join(movedDir, "Button.tsx"), filepath.Join(movedDir, "icons", "Icon.tsx")}, files, "Files that aren't ignored should be reported")
+	assert.ElementsMatch(t, []string{movedDir, filepath.Join(movedDir, "icons")}, watcher.WatchList(), "Directories that aren't ignored should be watched")
+}
+
+func TestHotReload_HandleFileChanges_RegeneratesTypesOnce(t *testing.T) {
+	// The props structs don't exist, so every regeneration logs an error
+	var logs lockedBuffer
+	propsDir := filepath.Join(t.TempDir(), "models")
+	hr := newHotReload(&Engine{
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+		Config: &Config{FrontendDir: t.TempDir(), PropsStructsPath: filepath.Join(propsDir, "props.go")},
+	})
+
+	hr.handleFileChanges([]string{filepath.Join(propsDir, "props.go"), filepath.Join(propsDir, "user.go")})
+	assert.Eventually(t, func() bool { return strings.Contains(logs.String(), "Failed to regenerate types") }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, strings.Count(logs.String(), "Failed to regenerate types"), "Types should be regenerated once for all the changed files")
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHotReload_CheckOrigin(t *testing.T) {
+	hr := newHotReload(&Engine{Logger: slog.Default(), Config: &Config{HotReloadHost: "dev.local", HotReloadAllowedOrigins: []string{"https://dev.example.com"}}})
+	for origin, allowed := range map[string]bool{
+		"":                        true,
+		"http://localhost:3000":   true,
+		"http://127.0.0.1:8080":   true,
+		"http://[::1]:8080":       true,
+		"http://dev.local:8080":   true,
+		"https://dev.example.com": true,
+		"http://evil.com:3001":    false,
+		"https://example.com":     false,
+	} {
+		// A DNS rebinding page sends its own host name as the Host header
+		r := httptest.NewRequest(http.MethodGet, "http://evil.com:3001/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		assert.Equal(t, allowed, hr.checkOrigin(r), "Origin %q", origin)
+	}
+
+	hr.engine.Config.HotReloadHost = "0.0.0.0"
+	r := httptest.NewRequest(http.MethodGet, "http://0.0.0.0:3001/ws", nil)
+	r.Header.Set("Origin", "http://0.0.0.0:3000")
+	assert.False(t, hr.checkOrigin(r), "Listening on all addresses shouldn't allow every origin")
+}
+
+func TestHotReload_Broadcast(t *testing.T) {
+	hr := newHotReload(&Engine{Logger: slog.Default(), Config: &Config{HotReloadMaxConnections: 10}})
+	server := httptest.NewServer(hr)
+	defer server.Close()
+	defer hr.Stop(context.Background())
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?token="+hr.token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	assert.Nil(t, ws.WriteMessage(websocket.TextMessage, []byte("home")))
+	var message hotReloadMessage
+	assert.Nil(t, ws.ReadJSON(&message))
+	assert.Equal(t, "connected", message.Type)
+	assert.Eventually(t, func() bool {
+		hr.mu.Lock()
+		defer hr.mu.Unlock()
+		return len(hr.connectedClients["home"]) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	hr.broadcast([]string{"home", "about"}, hotReloadMessage{Type: "reload"})
+	assert.Nil(t, ws.ReadJSON(&message))
+	assert.Equal(t, "reload", message.Type, "Clients of the routes should get the message")
+}
@@ -2,6 +2,8 @@
 
 package go_ssr
 
+import "context"
+
 // HotReload is a stub for production builds
 type HotReload struct{}
 
@@ -11,4 +13,7 @@ func newHotReload(engine *Engine) *HotReload {
 }
 
 // Start is a no-op in production
-func (hr *HotReload) Start() {}
+func (hr *HotReload) Start() error { return nil }
+
+// Stop is a no-op in production
+func (hr *HotReload) Stop(ctx context.Context) error { return nil }
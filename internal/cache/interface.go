@@ -30,7 +30,12 @@ type Cache interface {
 	SetParentFileDependencies(filePath string, dependencies []string) error
 	GetParentFilesFromDependency(dependencyPath string) ([]string, error)
 
-	// Clear removes all cached data
+	// GetCodeCache retrieves the compiled code of a JS bundle
+	GetCodeCache(key string) ([]byte, bool, error)
+	// SetCodeCache stores the compiled code of a JS bundle
+	SetCodeCache(key string, data []byte) error
+
+	// Clear removes all cached data, except the compiled code shared by the servers and their restarts
 	Clear() error
 }
 
@@ -207,6 +207,16 @@ func (cm *LocalCache) GetParentFilesFromDependency(dependencyPath string) ([]str
 	return result, nil
 }
 
+// GetCodeCache never finds compiled code, the JS runtime pool keeps it in memory already
+func (cm *LocalCache) GetCodeCache(key string) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+// SetCodeCache doesn't keep compiled code, the JS runtime pool keeps it in memory already
+func (cm *LocalCache) SetCodeCache(key string, data []byte) error {
+	return nil
+}
+
 // Clear removes all cached data
 func (cm *LocalCache) Clear() error {
 	cm.serverBuilds.lock.Lock()
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/yejune/gotossr/internal/reactbuilder"
@@ -255,10 +257,31 @@ func (rc *RedisCache) GetParentFilesFromDependency(dependencyPath string) ([]str
 	return result, nil
 }
 
+// GetCodeCache retrieves the compiled code of a JS bundle from Redis
+func (rc *RedisCache) GetCodeCache(key string) ([]byte, bool, error) {
+	ctx := context.Background()
+	data, err := rc.client.Get(ctx, rc.prefix+"code:"+key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// SetCodeCache stores the compiled code of a JS bundle in Redis
+func (rc *RedisCache) SetCodeCache(key string, data []byte) error {
+	ctx := context.Background()
+	return rc.client.Set(ctx, rc.prefix+"code:"+key, data, rc.ttl).Err()
+}
+
 // Clear removes all gossr keys from cache
+// Compiled code is kept, it only depends on the bundle and the V8 version and spares the next servers compiling it.
 func (rc *RedisCache) Clear() error {
 	ctx := context.Background()
 	pattern := rc.prefix + "*"
+	codePrefix := rc.prefix + "code:"
 	var cursor uint64
 	for {
 		keys, nextCursor, err := rc.client.Scan(ctx, cursor, pattern, 100).Result()
@@ -266,6 +289,9 @@ func (rc *RedisCache) Clear() error {
 			return err
 		}
 
+		keys = slices.DeleteFunc(keys, func(key string) bool {
+			return strings.HasPrefix(key, codePrefix)
+		})
 		if len(keys) > 0 {
 			if err := rc.client.Del(ctx, keys...).Err(); err != nil {
 				return err
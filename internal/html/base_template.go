@@ -6,12 +6,13 @@ const BaseTemplate = `<!DOCTYPE html>
 	<meta charset="UTF-8" />
 	<meta name="viewport" content="width=device-width, initial-scale=1.0" />
 	<title>{{ .Title }}</title>
+	{{if .ImportMap}}{{ .ImportMap }}{{end}}
 	{{range $k, $v := .MetaTags}} <meta name="{{$k}}" content="{{$v}}" /> {{end}}
 	{{range $k, $v := .OGMetaTags}} <meta property="{{$k}}" content="{{$v}}" /> {{end}}
 	{{range .Links}}<link href="{{.Href}}" rel="{{.Rel}}" media="{{.Media}}" hreflang="{{.Hreflang}}" type="{{.Type}}" title="{{.Title}}" />{{end}}
 	<link rel="icon" href="/favicon.ico" />
-	{{if .CSSPath}}<link rel="stylesheet" href="{{ .CSSPath }}" />
-	{{else}}<style>
+	{{if .CSSPath}}<link id="__gossr_css" rel="stylesheet" href="{{ .CSSPath }}" />
+	{{else}}<style id="__gossr_css">
 	  {{ .CSS }}}
 	</style>{{end}}
   </head>
@@ -26,6 +27,16 @@ const BaseTemplate = `<!DOCTYPE html>
 	  }
 	</script>
 	{{if .JSPath}}<script type="module" src="{{ .JSPath }}" onerror="showError('Failed to load script')"></script>
+	{{else if .ImportMap}}<script>
+	  function showModuleError(e) {
+		showError(e.error ? e.error.stack : e.message);
+	  }
+	  window.addEventListener("error", showModuleError, { once: true });
+	</script>
+	<script type="module" onerror="showError('Failed to load script')">
+	  {{ .JS }}
+	  ;window.removeEventListener("error", showModuleError);
+	</script>
 	{{else}}<script type="module">
 	  try{
 		{{ .JS }}
@@ -34,19 +45,7 @@ const BaseTemplate = `<!DOCTYPE html>
 	  }
 	</script>{{end}}
 	{{if .IsDev}}
-	<script>
-	  let socket = new WebSocket("ws://127.0.0.1:3001/ws");
-	  socket.onopen = () => {
-		socket.send({{ .RouteID }});
-	  };
-
-	  socket.onmessage = (event) => {
-		if (event.data === "reload") {
-		  console.log("Change detected, reloading...");
-		  window.location.reload();
-		}
-	  };
-	</script>
+	` + hotReloadClient + `
 	{{end}}
   </body>
 </html>
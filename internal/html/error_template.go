@@ -14,25 +14,91 @@ const ErrorTemplate = `<!DOCTYPE html>
 	h1 {
 		margin-bottom: 12px;
 	}
+	{{if .IsDev}}
+	body {
+		margin: 0;
+		background: #18181b;
+		color: #e4e4e7;
+	}
+	.overlay {
+		max-width: 960px;
+		margin: 0 auto;
+		padding: 32px 24px;
+	}
+	.overlay h1 {
+		color: #f87171;
+		font-size: 20px;
+	}
+	.overlay pre {
+		font-family: Menlo, Consolas, monospace;
+		font-size: 13px;
+		white-space: pre-wrap;
+		word-break: break-word;
+	}
+	.message {
+		font-size: 16px !important;
+		color: #fecaca;
+	}
+	.location a {
+		color: #93c5fd;
+	}
+	.frame {
+		background: #09090b;
+		border-radius: 6px;
+		padding: 12px 0;
+		overflow-x: auto;
+	}
+	.frame div {
+		padding: 0 16px;
+		white-space: pre;
+	}
+	.frame .highlight {
+		background: #450a0a;
+	}
+	.frame .caret {
+		color: #f87171;
+	}
+	.frame .number {
+		color: #71717a;
+		user-select: none;
+	}
+	.overlay h2 {
+		font-size: 14px;
+		color: #a1a1aa;
+		margin-top: 24px;
+	}
+	.overlay details summary {
+		cursor: pointer;
+		color: #a1a1aa;
+	}
+	.footer {
+		color: #71717a;
+		font-size: 13px;
+		margin-top: 32px;
+	}
+	{{end}}
 	</style>
   </head>
   <body>
-	<h1>An error occured</h1>
-	<code>{{ .Error }}</code>
 	{{if .IsDev}}
-		<script>
-		  let socket = new WebSocket("ws://127.0.0.1:3001/ws");
-		  socket.onopen = () => {
-			socket.send({{ .RouteID }});
-		  };
-	
-		  socket.onmessage = (event) => {
-			if (event.data === "reload") {
-			  console.log("Change detected, reloading...");
-			  window.location.reload();
-			}
-		  };
-		</script>
+	<div class="overlay">
+	  <h1>{{ .Title }}</h1>
+	  <pre class="message">{{ .Error }}</pre>
+	  {{if .File}}<p class="location">{{if .EditorURL}}<a href="{{ .EditorURL }}">{{ .DisplayFile }}:{{ .Line }}:{{ .Column }}</a>{{else}}{{ .DisplayFile }}:{{ .Line }}:{{ .Column }}{{end}}</p>{{end}}
+	  {{if .CodeFrame}}<pre class="frame">{{range .CodeFrame}}<div{{if .Highlight}} class="highlight"{{end}}><span class="number">{{ printf "%4d" .Number }} | </span>{{ .Text }}</div>{{if .Caret}}<div class="caret"><span class="number">     | </span>{{ .Caret }}</div>{{end}}{{end}}</pre>{{end}}
+	  {{if .ComponentStack}}<h2>Component stack</h2>
+	  <pre>{{range .ComponentStack}}{{ . }}
+{{end}}</pre>{{end}}
+	  {{if .Stack}}<details>
+		<summary>Stack trace</summary>
+		<pre>{{ .Stack }}</pre>
+	  </details>{{end}}
+	  <p class="footer">This page reloads automatically once the error is fixed.</p>
+	</div>
+	` + hotReloadClient + `
+	{{else}}
+	<h1>An error occured</h1>
+	<p>Something went wrong while rendering this page. Please try again later.</p>
 	{{end}}
   </body>
 </html>
@@ -0,0 +1,77 @@
+package html
+
+// hotReloadClient connects to the hot reload server and applies the updates it pushes.
+// The websocket URL is derived from the page location, so it works behind proxies and over https.
+// Messages are JSON objects with a "type":
+//   - "reload": reload the page
+//   - "hmr-update": evaluate "code", a bundle that hot updates "file" with React Refresh
+//   - "css-update": replace the page stylesheet with "css"
+//   - "types-updated": show a notice that the props types in "file" were regenerated
+const hotReloadClient = `<script>
+	  (() => {
+		const protocol = window.location.protocol === "https:" ? "wss:" : "ws:";
+		const port = {{ .HotReload.Port }};
+		const host = port ? window.location.hostname + ":" + port : window.location.host;
+		const socket = new WebSocket(protocol + "//" + host + {{ .HotReload.Path }} + "?token=" + encodeURIComponent({{ .HotReload.Token }}));
+		socket.onopen = () => {
+		  socket.send({{ .RouteID }});
+		};
+
+		const showNotice = (text) => {
+		  const notice = document.createElement("div");
+		  notice.textContent = text;
+		  notice.style.cssText = "position:fixed;right:16px;bottom:16px;z-index:2147483647;padding:10px 14px;border-radius:6px;background:#1e1e1e;color:#e6e6e6;font:13px Menlo,Consolas,monospace;box-shadow:0 4px 16px rgba(0,0,0,.3)";
+		  document.body.appendChild(notice);
+		  setTimeout(() => notice.remove(), 5000);
+		};
+
+		socket.onmessage = (event) => {
+		  let message;
+		  try {
+			message = JSON.parse(event.data);
+		  } catch (e) {
+			return;
+		  }
+		  switch (message.type) {
+			case "reload":
+			  console.log("Change detected, reloading...");
+			  window.location.reload();
+			  break;
+			case "hmr-update":
+			  // Pages without React Refresh (e.g. the error page) can't be hot updated
+			  if (!window.__gossr_hmr) {
+				window.location.reload();
+				return;
+			  }
+			  try {
+				(0, eval)(message.code);
+			  } catch (e) {
+				console.error("[gossr] Hot update failed, reloading...", e);
+				window.location.reload();
+			  }
+			  break;
+			case "css-update": {
+			  const current = document.getElementById("__gossr_css");
+			  if (!current) {
+				window.location.reload();
+				return;
+			  }
+			  // Swap a <link> for an inline <style> so that later updates can be applied in place
+			  let style = current;
+			  if (current.tagName !== "STYLE") {
+				style = document.createElement("style");
+				style.id = "__gossr_css";
+				current.replaceWith(style);
+			  }
+			  style.textContent = message.css;
+			  console.log("[gossr] Updated styles");
+			  break;
+			}
+			case "types-updated":
+			  console.log("[gossr] Regenerated " + message.file);
+			  showNotice("Props types changed, regenerated " + message.file);
+			  break;
+		  }
+		};
+	  })();
+	</script>`
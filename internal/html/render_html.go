@@ -2,10 +2,8 @@ package html
 
 import (
 	"bytes"
-	"fmt"
 	"html/template"
 	"os"
-	"runtime"
 	"strings"
 )
 
@@ -24,13 +22,22 @@ type Params struct {
 	JS         template.JS
 	JSPath     string // External JS file path (if set, use <script src> instead of inline)
 	CSS        template.CSS
-	CSSPath    string // External CSS file path (if set, use <link href> instead of inline)
-	PropsJSON  template.JS // SSR props as JSON for client hydration
+	CSSPath    string        // External CSS file path (if set, use <link href> instead of inline)
+	PropsJSON  template.JS   // SSR props as JSON for client hydration
+	ImportMap  template.HTML // <script type="importmap"> tag for packages loaded from a CDN
 	RouteID    string
 	IsDev      bool
+	HotReload  HotReloadParams
 	ServerHTML template.HTML
 }
 
+// HotReloadParams tells the dev client where the hot reload websocket is served
+type HotReloadParams struct {
+	Port  int    // Port of the standalone hot reload server, 0 when it's mounted on the app's own server
+	Path  string // Path of the websocket endpoint, e.g. "/ws"
+	Token string // Per-process token the server requires to accept the connection
+}
+
 // RenderHTMLString Renders the HTML template in internal/html with the given parameters
 func RenderHTMLString(params Params) []byte {
 	params.IsDev = os.Getenv("APP_ENV") != "production"
@@ -40,7 +47,12 @@ func RenderHTMLString(params Params) []byte {
 	var output bytes.Buffer
 	err := t.Execute(&output, params)
 	if err != nil {
-		return RenderError(err, params.RouteID)
+		return RenderErrorPage(ErrorParams{
+			Title:     "An error occured",
+			Error:     err.Error(),
+			RouteID:   params.RouteID,
+			HotReload: params.HotReload,
+		})
 	}
 	return output.Bytes()
 }
@@ -66,20 +78,73 @@ func getOGMetaTags(metaTags map[string]string) map[string]string {
 }
 
 type ErrorParams struct {
-	Error   string
-	RouteID string
-	IsDev   bool
+	Error          string
+	RouteID        string
+	IsDev          bool
+	Title          string
+	File           string // Absolute path of the file that caused the error
+	DisplayFile    string // File as shown in the overlay, e.g. relative to the frontend dir
+	Line           int
+	Column         int
+	EditorURL      template.URL // Link that opens File in an editor, trusted because it comes from the engine config
+	CodeFrame      []CodeFrameLine
+	ComponentStack []string
+	Stack          string
+	HotReload      HotReloadParams
+}
+
+// CodeFrameLine is a single source line shown around the error location
+type CodeFrameLine struct {
+	Number    int
+	Text      string
+	Highlight bool
+	Caret     string // Marker pointing at the error column, only set on the highlighted line
 }
 
 // RenderError Renders the error template with the given error
+// Details are only shown in development, production shows a generic message
 func RenderError(e error, routeID string) []byte {
-	t := template.Must(template.New("").Parse(ErrorTemplate))
-	var output bytes.Buffer
-	_, filename, line, _ := runtime.Caller(1)
-	t.Execute(&output, ErrorParams{
-		Error:   fmt.Sprintf("%s line %d: %v", filename, line, e),
+	return RenderErrorPage(ErrorParams{
+		Title:   "An error occured",
+		Error:   e.Error(),
 		RouteID: routeID,
-		IsDev:   os.Getenv("APP_ENV") != "production",
 	})
+}
+
+// RenderErrorPage renders the error template, showing the dev overlay outside production
+func RenderErrorPage(params ErrorParams) []byte {
+	params.IsDev = os.Getenv("APP_ENV") != "production"
+	if !params.IsDev {
+		// Never leak error details or file paths in production
+		params = ErrorParams{RouteID: params.RouteID}
+	}
+	t := template.Must(template.New("").Parse(ErrorTemplate))
+	var output bytes.Buffer
+	t.Execute(&output, params)
 	return output.Bytes()
 }
+
+// BuildCodeFrame returns the lines of source around line, highlighting line and pointing at column
+func BuildCodeFrame(source string, line, column, contextLines int) []CodeFrameLine {
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+	start := max(line-contextLines, 1)
+	end := min(line+contextLines, len(lines))
+	frame := make([]CodeFrameLine, 0, end-start+1)
+	for n := start; n <= end; n++ {
+		text := strings.TrimRight(strings.ReplaceAll(lines[n-1], "\t", "  "), "\r")
+		frameLine := CodeFrameLine{Number: n, Text: text, Highlight: n == line}
+		if n == line && column > 0 {
+			// Tabs are expanded above, so expand them in the prefix too to keep the caret aligned
+			prefix := lines[n-1]
+			if column-1 < len(prefix) {
+				prefix = prefix[:column-1]
+			}
+			frameLine.Caret = strings.Repeat(" ", len(strings.ReplaceAll(prefix, "\t", "  "))) + "^"
+		}
+		frame = append(frame, frameLine)
+	}
+	return frame
+}
@@ -2,6 +2,7 @@ package jsruntime
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -62,75 +63,126 @@ var app = createElement('div', { id: 'root', class: 'container' }, [
 renderToString(app);
 `
 
-func BenchmarkRuntime_Simple(b *testing.B) {
-	pool := NewPool(PoolConfig{
-		PoolSize: 10,
+// newBenchmarkPool creates a pool of runtimeType that is closed when the benchmark ends
+func newBenchmarkPool(tb testing.TB, runtimeType RuntimeType, size int) *Pool {
+	pool, err := NewPool(PoolConfig{
+		RuntimeType: runtimeType,
+		PoolSize:    size,
 	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(pool.Close)
+	return pool
+}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, err := pool.Execute(simpleJS)
-		if err != nil {
-			b.Fatal(err)
-		}
+func BenchmarkRuntime_Simple(b *testing.B) {
+	for _, runtimeType := range AvailableRuntimes() {
+		b.Run(string(runtimeType), func(b *testing.B) {
+			pool := newBenchmarkPool(b, runtimeType, 10)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := pool.Execute(simpleJS)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
 	}
 }
 
 func BenchmarkRuntime_Complex(b *testing.B) {
-	pool := NewPool(PoolConfig{
-		PoolSize: 10,
-	})
+	for _, runtimeType := range AvailableRuntimes() {
+		b.Run(string(runtimeType), func(b *testing.B) {
+			pool := newBenchmarkPool(b, runtimeType, 10)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := pool.Execute(complexJS)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, err := pool.Execute(complexJS)
-		if err != nil {
-			b.Fatal(err)
-		}
+func BenchmarkRuntime_ExecuteWithProps(b *testing.B) {
+	// The bundle reads its props from the injected props variable
+	bundle := strings.Replace(complexJS, `var props = { count: 42, name: "Test" };`, "", 1)
+	for _, runtimeType := range AvailableRuntimes() {
+		b.Run(string(runtimeType), func(b *testing.B) {
+			pool := newBenchmarkPool(b, runtimeType, 10)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := pool.ExecuteWithProps(bundle, `{"count": 42, "name": "Test"}`)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
 	}
 }
 
 func BenchmarkRuntime_NoPool(b *testing.B) {
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		rt := newRuntime()
-		_, err := rt.Execute(simpleJS)
-		if err != nil {
-			b.Fatal(err)
-		}
-		rt.Destroy()
+	for _, runtimeType := range AvailableRuntimes() {
+		b.Run(string(runtimeType), func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rt, err := NewRuntime(runtimeType)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_, err = rt.Execute(simpleJS)
+				if err != nil {
+					b.Fatal(err)
+				}
+				rt.Destroy()
+			}
+		})
 	}
 }
 
 func BenchmarkRuntime_Parallel(b *testing.B) {
-	pool := NewPool(PoolConfig{
-		PoolSize: 20,
-	})
+	for _, runtimeType := range AvailableRuntimes() {
+		b.Run(string(runtimeType), func(b *testing.B) {
+			pool := newBenchmarkPool(b, runtimeType, 20)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, err := pool.Execute(complexJS)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
 
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		for pb.Next() {
-			_, err := pool.Execute(complexJS)
+func TestRuntimeOutput(t *testing.T) {
+	for _, runtimeType := range AvailableRuntimes() {
+		t.Run(string(runtimeType), func(t *testing.T) {
+			rt, err := NewRuntime(runtimeType)
 			if err != nil {
-				b.Fatal(err)
+				t.Fatal(err)
 			}
-		}
-	})
-}
+			defer rt.Destroy()
 
-func TestRuntimeOutput(t *testing.T) {
-	rt := newRuntime()
-	defer rt.Destroy()
+			result, err := rt.Execute(complexJS)
+			if err != nil {
+				t.Fatalf("Runtime error: %v", err)
+			}
 
-	result, err := rt.Execute(complexJS)
-	if err != nil {
-		t.Fatalf("Runtime error: %v", err)
-	}
+			expected := `<div id="root" class="container"><h1>Hello Test</h1><p>Count: 42</p><ul><li>Item 1</li><li>Item 2</li><li>Item 3</li></ul></div>`
+			if result != expected {
+				t.Errorf("Unexpected result:\nGot: %s\nExpected: %s", result, expected)
+			}
 
-	expected := `<div id="root" class="container"><h1>Hello Test</h1><p>Count: 42</p><ul><li>Item 1</li><li>Item 2</li><li>Item 3</li></ul></div>`
-	if result != expected {
-		t.Errorf("Unexpected result:\nGot: %s\nExpected: %s", result, expected)
+			fmt.Printf("Runtime: %s\nOutput: %s\n", runtimeType, result)
+		})
 	}
-
-	fmt.Printf("Runtime: %s\nOutput: %s\n", DefaultRuntimeType(), result)
 }
@@ -0,0 +1,185 @@
+package jsruntime
+
+import (
+	"container/list"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// memoryCodeCacheSize is the size of the compiled code a MemoryCodeCache keeps, in bytes
+	memoryCodeCacheSize = 64 * 1024 * 1024
+	// dirCodeCacheSize is the size of the compiled code a DirCodeCache keeps, in bytes
+	dirCodeCacheSize = 256 * 1024 * 1024
+)
+
+// CodeCache stores the compiled code of bundles, keyed by runtime version and bundle hash
+// Runtimes sharing one compile each bundle once. cache.Cache implements it.
+type CodeCache interface {
+	GetCodeCache(key string) ([]byte, bool, error)
+	SetCodeCache(key string, data []byte) error
+}
+
+// MemoryCodeCache keeps compiled code in memory, in front of an optional persistent cache
+// The least recently used code is dropped when the cache grows beyond maxSize bytes.
+type MemoryCodeCache struct {
+	next    CodeCache
+	maxSize int
+	size    int
+	entries map[string]*list.Element // Elements of recent, holding *codeCacheEntry
+	recent  *list.List               // Most recently used first
+	mu      sync.Mutex
+}
+
+type codeCacheEntry struct {
+	key  string
+	data []byte
+}
+
+// NewMemoryCodeCache creates a code cache reading missing code from next and writing new code through to it
+// next may be nil to only keep the code in memory.
+func NewMemoryCodeCache(next CodeCache) *MemoryCodeCache {
+	return &MemoryCodeCache{
+		next:    next,
+		maxSize: memoryCodeCacheSize,
+		entries: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+func (c *MemoryCodeCache) GetCodeCache(key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		c.recent.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	if ok {
+		return element.Value.(*codeCacheEntry).data, true, nil
+	}
+	if c.next == nil {
+		return nil, false, nil
+	}
+
+	data, ok, err := c.next.GetCodeCache(key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	c.add(key, data)
+	return data, true, nil
+}
+
+func (c *MemoryCodeCache) SetCodeCache(key string, data []byte) error {
+	c.add(key, data)
+	if c.next == nil {
+		return nil
+	}
+	return c.next.SetCodeCache(key, data)
+}
+
+// add keeps data as the most recently used code and drops the least recently used code beyond maxSize
+func (c *MemoryCodeCache) add(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.size -= len(element.Value.(*codeCacheEntry).data)
+		c.recent.Remove(element)
+	}
+	c.entries[key] = c.recent.PushFront(&codeCacheEntry{key: key, data: data})
+	c.size += len(data)
+	for c.size > c.maxSize && c.recent.Len() > 1 {
+		oldest := c.recent.Remove(c.recent.Back()).(*codeCacheEntry)
+		delete(c.entries, oldest.key)
+		c.size -= len(oldest.data)
+	}
+}
+
+// DirCodeCache keeps compiled code in the files of a directory, so it survives restarts
+// Files are pruned from the least recently used when they add up to more than maxSize bytes, which also removes the
+// code of bundles that no longer exist and of previous runtime versions.
+type DirCodeCache struct {
+	dir     string
+	maxSize int64
+}
+
+// NewDirCodeCache creates a code cache in dir, creating the directory if needed
+func NewDirCodeCache(dir string) (*DirCodeCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &DirCodeCache{dir: dir, maxSize: dirCodeCacheSize}
+	return c, c.prune()
+}
+
+func (c *DirCodeCache) GetCodeCache(key string) ([]byte, bool, error) {
+	path := filepath.Join(c.dir, key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	// The modification time tracks the last use, atime isn't updated on every file system
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, true, nil
+}
+
+func (c *DirCodeCache) SetCodeCache(key string, data []byte) error {
+	// Write to a temporary file first so other processes never read partial code
+	file, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), filepath.Join(c.dir, key))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return c.prune()
+}
+
+// prune removes the least recently used files beyond maxSize
+// Temporary files being written by other processes are left alone.
+func (c *DirCodeCache) prune() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	var files []fs.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+		// Files removed meanwhile by another process are skipped
+		if info, err := entry.Info(); err == nil {
+			files = append(files, info)
+		}
+	}
+	slices.SortFunc(files, func(a, b fs.FileInfo) int {
+		return b.ModTime().Compare(a.ModTime())
+	})
+	var size int64
+	for _, file := range files {
+		size += file.Size()
+		if size > c.maxSize {
+			if err := os.Remove(filepath.Join(c.dir, file.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package jsruntime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeCache(t *testing.T) {
+	dirCache, err := NewDirCodeCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ok, err := dirCache.GetCodeCache("v8-1.0-abc")
+	assert.NoError(t, err)
+	assert.False(t, ok, "missing code is a miss, not an error")
+
+	// Code set through the memory cache is persisted, a new memory cache reads it back
+	assert.NoError(t, NewMemoryCodeCache(dirCache).SetCodeCache("v8-1.0-abc", []byte("code")))
+	restarted := NewMemoryCodeCache(dirCache)
+	data, ok, err := restarted.GetCodeCache("v8-1.0-abc")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("code"), data)
+
+	memoryCache := NewMemoryCodeCache(nil)
+	assert.NoError(t, memoryCache.SetCodeCache("v8-1.0-abc", []byte("code")))
+	data, ok, _ = memoryCache.GetCodeCache("v8-1.0-abc")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("code"), data)
+}
+
+func TestMemoryCodeCache_Evict(t *testing.T) {
+	cache := NewMemoryCodeCache(nil)
+	cache.maxSize = 8
+	assert.NoError(t, cache.SetCodeCache("a", []byte("aaaa")))
+	assert.NoError(t, cache.SetCodeCache("b", []byte("bbbb")))
+	// Reading a makes b the least recently used
+	_, ok, _ := cache.GetCodeCache("a")
+	assert.True(t, ok)
+	assert.NoError(t, cache.SetCodeCache("c", []byte("cccc")))
+
+	_, ok, _ = cache.GetCodeCache("b")
+	assert.False(t, ok, "The least recently used code should be dropped beyond the size limit")
+	for _, key := range []string{"a", "c"} {
+		_, ok, _ = cache.GetCodeCache(key)
+		assert.True(t, ok, "Recently used code should be kept")
+	}
+	assert.Equal(t, 8, cache.size)
+}
+
+func TestDirCodeCache_Prune(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDirCodeCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.maxSize = 8
+	assert.NoError(t, cache.SetCodeCache("v8-1.0-a", []byte("aaaa")))
+	assert.NoError(t, cache.SetCodeCache("v8-1.0-b", []byte("bbbb")))
+	// Files written in the same instant need distinct times to be ordered
+	old := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "v8-1.0-a"), old, old))
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "v8-1.0-b"), old.Add(-time.Minute), old.Add(-time.Minute)))
+	// Reading a makes b the least recently used
+	_, ok, _ := cache.GetCodeCache("v8-1.0-a")
+	assert.True(t, ok)
+	assert.NoError(t, cache.SetCodeCache("v8-1.0-c", []byte("cccc")))
+
+	_, ok, _ = cache.GetCodeCache("v8-1.0-b")
+	assert.False(t, ok, "The least recently used file should be removed beyond the size limit")
+	for _, key := range []string{"v8-1.0-a", "v8-1.0-c"} {
+		_, ok, _ = cache.GetCodeCache(key)
+		assert.True(t, ok, "Recently used files should be kept")
+	}
+}
@@ -0,0 +1,148 @@
+package jsruntime
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yejune/gotossr/internal/reactbuilder"
+)
+
+// newConformanceRuntime creates a runtime of runtimeType that is destroyed when the test ends
+func newConformanceRuntime(t *testing.T, runtimeType RuntimeType) JSRuntime {
+	rt, err := NewRuntime(runtimeType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rt.Destroy)
+	return rt
+}
+
+// TestConformance checks that every runtime compiled in returns the same results as V8
+func TestConformance(t *testing.T) {
+	for _, runtimeType := range AvailableRuntimes() {
+		t.Run(string(runtimeType), func(t *testing.T) {
+			t.Run("results", func(t *testing.T) {
+				rt := newConformanceRuntime(t, runtimeType)
+				for code, expected := range map[string]string{
+					`"Hello " + "world"`:         "Hello world",
+					`"<p class=\"a\">&amp;</p>"`: `<p class="a">&amp;</p>`,
+					`"héllo wörld 👋"`:            "héllo wörld 👋",
+					`""`:                         "",
+					`undefined`:                  "undefined",
+					`42`:                         "42",
+					`-7`:                         "-7",
+					`1.5`:                        "1.5",
+					`0.1 + 0.2`:                  "0.30000000000000004",
+					`1 / 3`:                      "0.3333333333333333",
+					`-0`:                         "0",
+					`1e21`:                       "1e+21",
+					`123456789012345680000`:      "123456789012345680000",
+					`1e-7`:                       "1e-7",
+					`0.000001`:                   "0.000001",
+					`1 / 0`:                      "Infinity",
+					`-1 / 0`:                     "-Infinity",
+					`0 / 0`:                      "NaN",
+					`true`:                       "true",
+					`null`:                       "null",
+				} {
+					result, err := rt.Execute(code)
+					assert.Nil(t, err, "Execute should not return an error for %s", code)
+					assert.Equal(t, expected, result, "Unexpected result for %s", code)
+				}
+			})
+
+			t.Run("scope", func(t *testing.T) {
+				if ESTarget(runtimeType) == "es5" {
+					t.Skip("let, const and class aren't ES5")
+				}
+				// Top level declarations are global, like in a <script>, and seen by the next executions
+				rt := newConformanceRuntime(t, runtimeType)
+				_, err := rt.Execute("let a = 1; const b = 2; class C {}")
+				assert.Nil(t, err, "Execute should not return an error")
+				result, err := rt.Execute("a + b + typeof C")
+				assert.Nil(t, err, "Top level declarations should be visible to the next executions")
+				assert.Equal(t, "3function", result)
+
+				result, err = rt.Execute("String(new Error('boom'))")
+				assert.Nil(t, err, "Execute should not return an error")
+				assert.Equal(t, "Error: boom", result, "Errors converted by scripts shouldn't include the stack")
+			})
+
+			t.Run("unicode", func(t *testing.T) {
+				// The TextEncoder polyfill of server bundles is used by react-dom/server to encode chunks
+				build, err := reactbuilder.BuildServer(`globalThis.__ssr_result = Array.prototype.join.call(new TextEncoder().encode("é€👋"), ",")`, t.TempDir(), "/assets", ESTarget(runtimeType))
+				if err != nil {
+					t.Fatal(err)
+				}
+				result, err := newConformanceRuntime(t, runtimeType).Execute(build.JS)
+				assert.Nil(t, err, "Execute should not return an error")
+				assert.Equal(t, "195,169,226,130,172,240,159,145,139", result, "Strings should be encoded to UTF-8")
+			})
+
+			t.Run("errors", func(t *testing.T) {
+				rt := newConformanceRuntime(t, runtimeType)
+				_, err := rt.ExecuteWithProps("function render() {\n  throw new Error('boom')\n}\nrender()", `{}`)
+				if assert.NotNil(t, err, "Thrown errors should be returned") {
+					assert.Contains(t, err.Error(), "Error: boom", "The error should include the message")
+					assert.Contains(t, err.Error(), "render", "The error should include the stack")
+				}
+
+				_, err = rt.Execute("function (")
+				assert.NotNil(t, err, "Syntax errors should be returned")
+
+				// The runtime stays usable after an error
+				rt.Reset()
+				result, err := rt.Execute(`"ok"`)
+				assert.Nil(t, err, "Execute should not return an error after a failed execution")
+				assert.Equal(t, "ok", result)
+			})
+
+			t.Run("props", func(t *testing.T) {
+				rt := newConformanceRuntime(t, runtimeType)
+				bundle := `globalThis.__ssr_result = props.name + " " + props.items.length + " " + props.nested.ok; globalThis.__ssr_result`
+				for _, name := range []string{"a", "ü"} {
+					result, err := rt.ExecuteWithProps(bundle, `{"name": "`+name+`", "items": [1, 2], "nested": {"ok": true}}`)
+					assert.Nil(t, err, "ExecuteWithProps should not return an error")
+					assert.Equal(t, name+" 2 true", result, "Props should be injected on every execution")
+					rt.Reset()
+				}
+
+				_, err := rt.ExecuteWithProps(bundle, `{`)
+				assert.NotNil(t, err, "Invalid props should be returned as an error")
+			})
+
+			t.Run("threads", func(t *testing.T) {
+				// Pools hand runtimes to the goroutines of requests, which run on any thread
+				rt := newConformanceRuntime(t, runtimeType)
+				for range 3 {
+					done := make(chan struct{})
+					go func() {
+						defer close(done)
+						runtime.LockOSThread()
+						defer runtime.UnlockOSThread()
+						result, err := rt.ExecuteWithProps(`props.n + 1`, `{"n": 1}`)
+						assert.Nil(t, err, "ExecuteWithProps should not return an error on another thread")
+						assert.Equal(t, "2", result)
+						rt.Reset()
+					}()
+					<-done
+				}
+			})
+
+			t.Run("reset", func(t *testing.T) {
+				rt := newConformanceRuntime(t, runtimeType)
+				// Bundles reset their own state on every execution, e.g. __ssr_errors in the banner
+				bundle := `globalThis.__ssr_errors = []; if (props.fail) { __ssr_errors.push("failed") } globalThis.__ssr_result = props.name + __ssr_errors.length; globalThis.__ssr_result`
+				result, err := rt.ExecuteWithProps(bundle, `{"name": "a", "fail": true}`)
+				assert.Nil(t, err, "ExecuteWithProps should not return an error")
+				assert.Equal(t, "a1", result)
+				rt.Reset()
+
+				result, err = rt.ExecuteWithProps(bundle, `{"name": "b"}`)
+				assert.Nil(t, err, "ExecuteWithProps should not return an error")
+				assert.Equal(t, "b0", result, "State of the previous execution should not leak into the next one")
+			})
+		})
+	}
+}
@@ -3,19 +3,21 @@
 package jsruntime
 
 import (
+	"errors"
+	"fmt"
 	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	djs "github.com/rosbit/dukgo"
 )
 
 func init() {
-	defaultRuntimeType = RuntimeDukgo
-	defaultESTarget = "es5" // Duktape only supports ES5
-}
-
-// newRuntime creates the default runtime for this build
-func newRuntime() JSRuntime {
-	return NewDukgoRuntime()
+	registerRuntime(RuntimeDukgo, func(RuntimeOptions) JSRuntime { return NewDukgoRuntime() })
+	esTargets[RuntimeDukgo] = "es5" // Duktape only supports ES5
+	// Code runs through eval, whose var declarations are deletable. Contexts are recreated on every Reset anyway.
+	untrackedGlobals[RuntimeDukgo] = true
 }
 
 // DukgoRuntime wraps Duktape via dukgo for pooled usage
@@ -34,32 +36,53 @@ func NewDukgoRuntime() *DukgoRuntime {
 	}
 }
 
+// dukgoEval runs the code in __gossr_code and keeps its result, converted with String(), in a global.
+// dukgo returns strings that point into the Duktape heap, so results must stay referenced until they are copied.
+// Thrown errors are rethrown with their stack, as dukgo only reports the exception's string value.
+const dukgoEval = `try{var __gossr_r=(0,eval)(__gossr_code);__gossr_result=String(__gossr_r)}catch(e){throw e&&e.stack?e.stack:e}`
+
 // Execute runs JavaScript code and returns the result
 func (d *DukgoRuntime) Execute(code string) (string, error) {
-	res, err := d.context.Eval(code, nil)
+	res, err := d.context.Eval(dukgoEval, map[string]interface{}{"__gossr_code": code})
 	if err != nil {
-		return "", err
-	}
-
-	if res == nil {
-		return "", nil
+		return "", errors.New(decodeCESU8(err.Error()))
 	}
+	result, _ := res.(string)
+	return decodeCESU8(strings.Clone(result)), nil
+}
 
-	// Convert result to string
-	switch v := res.(type) {
-	case string:
-		return v, nil
-	default:
-		// For other types, convert using fmt.Sprintf
-		str, err := d.context.CallFunc("String", res)
-		if err != nil {
-			return "", err
+// decodeCESU8 converts the strings returned by Duktape to UTF-8
+// Duktape encodes characters outside of the BMP as surrogate pairs of 3 bytes each (CESU-8).
+func decodeCESU8(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		// A high surrogate (U+D800-U+DBFF) followed by a low surrogate (U+DC00-U+DFFF)
+		if i+5 < len(s) && s[i] == 0xED && s[i+1]&0xF0 == 0xA0 && s[i+3] == 0xED && s[i+4]&0xF0 == 0xB0 {
+			if b == nil {
+				b = append(make([]byte, 0, len(s)), s[:i]...)
+			}
+			high := 0xD000 | rune(s[i+1]&0x3F)<<6 | rune(s[i+2]&0x3F)
+			low := 0xD000 | rune(s[i+4]&0x3F)<<6 | rune(s[i+5]&0x3F)
+			b = utf8.AppendRune(b, utf16.DecodeRune(high, low))
+			i += 5
+			continue
 		}
-		if s, ok := str.(string); ok {
-			return s, nil
+		if b != nil {
+			b = append(b, s[i])
 		}
-		return "", nil
 	}
+	if b == nil {
+		return s
+	}
+	return string(b)
+}
+
+// ExecuteWithProps runs bundle with props (Duktape has no compiled script cache, so props are evaluated first)
+func (d *DukgoRuntime) ExecuteWithProps(bundle, propsJSON string) (string, error) {
+	if _, err := d.context.Eval("var props = "+propsJSON+";", nil); err != nil {
+		return "", fmt.Errorf("props error: %w", err)
+	}
+	return d.Execute(bundle)
 }
 
 // Reset prepares the runtime for reuse
@@ -0,0 +1,75 @@
+//go:build use_dukgo
+
+package jsruntime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yejune/gotossr/internal/reactbuilder"
+)
+
+func TestDukgoRuntime_ExecuteWithProps(t *testing.T) {
+	rt := NewDukgoRuntime()
+	defer rt.Destroy()
+
+	bundle := `globalThis.__ssr_result = "Hello " + props.name; globalThis.__ssr_result`
+	for _, name := range []string{"a", "b"} {
+		result, err := rt.ExecuteWithProps(bundle, `{"name": "`+name+`"}`)
+		assert.Nil(t, err, "ExecuteWithProps should not return an error")
+		assert.Equal(t, "Hello "+name, result, "Props should be injected on every execution")
+		rt.Reset()
+	}
+	assert.Equal(t, "es5", ESTarget(RuntimeDukgo), "Bundles should be lowered to ES5 for Duktape")
+}
+
+func TestDukgoRuntime_RenderTSX(t *testing.T) {
+	// A TSX page written in ES5 plus the syntax esbuild lowers, rendered by a minimal createElement instead of React
+	frontendDir := t.TempDir()
+	files := map[string]string{
+		"jsx.ts": `function escape(text: string): string {
+  return text.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+}
+function render(child: any): string {
+  if (child instanceof Array) return child.map(render).join("");
+  return typeof child === "object" && child !== null ? child.html : escape(String(child));
+}
+export function createElement(tag: string, attributes: { [name: string]: string } | null): { html: string } {
+  var children = Array.prototype.slice.call(arguments, 2);
+  var attrs = Object.keys(attributes || {}).map((name) => ` + "` ${name}=\"${escape(attributes![name])}\"`" + `).join("");
+  return { html: "<" + tag + attrs + ">" + render(children) + "</" + tag + ">" };
+}`,
+		"Home.tsx": `import * as React from "./jsx";
+
+interface HomeProps {
+  name: string;
+  items: string[];
+}
+
+export default function Home(props: HomeProps) {
+  return (
+    <main className="home">
+      <h1>Hello {props.name}</h1>
+      <ul>{props.items.map((item) => <li>{item}</li>)}</ul>
+    </main>
+  );
+}`,
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(frontendDir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	build, err := reactbuilder.BuildServer(`import Home from "./Home"; globalThis.__ssr_result = Home(props).html`, frontendDir, "/assets", ESTarget(RuntimeDukgo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := NewDukgoRuntime()
+	defer rt.Destroy()
+	result, err := rt.ExecuteWithProps(build.JS, `{"name": "<Duktape>", "items": ["a", "b"]}`)
+	assert.Nil(t, err, "ExecuteWithProps should not return an error")
+	assert.Equal(t, `<main className="home"><h1>Hello &lt;Duktape&gt;</h1><ul><li>a</li><li>b</li></ul></main>`, result)
+}
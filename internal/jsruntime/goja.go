@@ -0,0 +1,120 @@
+//go:build use_goja
+
+package jsruntime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+func init() {
+	registerRuntime(RuntimeGoja, func(RuntimeOptions) JSRuntime { return NewGojaRuntime() })
+}
+
+// GojaRuntime wraps goja (pure Go, no cgo) for pooled usage
+type GojaRuntime struct {
+	runtime        *goja.Runtime
+	cachedPrograms map[string]*goja.Program // hash -> compiled bundle
+}
+
+// NewGojaRuntime creates a new goja runtime
+func NewGojaRuntime() *GojaRuntime {
+	return &GojaRuntime{
+		runtime:        goja.New(),
+		cachedPrograms: make(map[string]*goja.Program),
+	}
+}
+
+// Execute runs JavaScript code and returns the result
+func (g *GojaRuntime) Execute(code string) (string, error) {
+	val, err := g.runtime.RunScript("render.js", code)
+	if err != nil {
+		return "", gojaError(err)
+	}
+	return gojaString(val), nil
+}
+
+// ExecuteWithProps runs a cached bundle with props injected
+// The bundle is compiled once to a goja.Program and cached, only the props script is compiled each request
+func (g *GojaRuntime) ExecuteWithProps(bundle, propsJSON string) (string, error) {
+	if _, err := g.runtime.RunScript("props.js", "var props = "+propsJSON+";"); err != nil {
+		return "", fmt.Errorf("props error: %w", gojaError(err))
+	}
+
+	program, err := g.program(bundle)
+	if err != nil {
+		return "", err
+	}
+
+	val, err := g.runtime.RunProgram(program)
+	if err != nil {
+		return "", gojaError(err)
+	}
+	return gojaString(val), nil
+}
+
+// Compile compiles a bundle ahead of its first ExecuteWithProps
+func (g *GojaRuntime) Compile(bundle string) error {
+	_, err := g.program(bundle)
+	return err
+}
+
+// program returns the compiled bundle, compiling it on first use
+func (g *GojaRuntime) program(bundle string) (*goja.Program, error) {
+	hash := hashBundle(bundle)
+	if program, ok := g.cachedPrograms[hash]; ok {
+		return program, nil
+	}
+	program, err := goja.Compile("bundle.js", bundle, false)
+	if err != nil {
+		return nil, fmt.Errorf("compile error: %w", err)
+	}
+	g.cachedPrograms[hash] = program
+	return program, nil
+}
+
+// gojaString converts a result to a string like JavaScript's String(), e.g. undefined is "undefined" as with V8
+func gojaString(val goja.Value) string {
+	if val == nil {
+		return ""
+	}
+	return val.String()
+}
+
+// gojaError formats a JS exception with a V8 style stack trace, so it can be remapped with the source map
+func gojaError(err error) error {
+	var exception *goja.Exception
+	if !errors.As(err, &exception) || exception.Value() == nil {
+		return err
+	}
+	var message strings.Builder
+	message.WriteString(exception.Value().String())
+	for _, frame := range exception.Stack() {
+		position := frame.Position()
+		if position.Filename == "" {
+			continue // Native function
+		}
+		location := fmt.Sprintf("%s:%d:%d", position.Filename, position.Line, position.Column)
+		if name := frame.FuncName(); name != "<anonymous>" {
+			location = name + " (" + location + ")"
+		}
+		message.WriteString("\n    at " + location)
+	}
+	return errors.New(message.String())
+}
+
+// Reset prepares the runtime for reuse
+// goja runtimes are cheap to create, so a new one clears the globals of the previous execution.
+// Compiled programs aren't bound to a runtime and stay cached.
+func (g *GojaRuntime) Reset() {
+	g.runtime = goja.New()
+}
+
+// Destroy permanently destroys the runtime
+func (g *GojaRuntime) Destroy() {
+	g.runtime = nil
+	g.cachedPrograms = nil
+}
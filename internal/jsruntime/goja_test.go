@@ -0,0 +1,26 @@
+//go:build use_goja
+
+package jsruntime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGojaRuntime_ExecuteWithProps(t *testing.T) {
+	rt := NewGojaRuntime()
+	defer rt.Destroy()
+
+	bundle := `globalThis.__ssr_result = "Hello " + props.name; globalThis.__ssr_result`
+	for _, name := range []string{"a", "b"} {
+		result, err := rt.ExecuteWithProps(bundle, `{"name": "`+name+`"}`)
+		assert.Nil(t, err, "ExecuteWithProps should not return an error")
+		assert.Equal(t, "Hello "+name, result, "Props should be injected on every execution")
+		rt.Reset()
+	}
+	assert.Len(t, rt.cachedPrograms, 1, "The bundle should be compiled once")
+
+	_, err := rt.ExecuteWithProps("function render() {\n  throw new Error('boom')\n}\nrender()", `{}`)
+	assert.EqualError(t, err, "Error: boom\n    at render (bundle.js:2:9)\n    at bundle.js:4:7", "Errors should carry a V8 style stack trace")
+}
@@ -4,38 +4,58 @@ package jsruntime
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unsafe"
 
+	"modernc.org/libc"
+	lib "modernc.org/libquickjs"
 	"modernc.org/quickjs"
 )
 
 func init() {
-	defaultRuntimeType = RuntimeModerncJS
-}
-
-// newRuntime creates the default runtime for this build
-func newRuntime() JSRuntime {
-	return NewModerncJSRuntime()
+	registerRuntime(RuntimeModerncJS, func(options RuntimeOptions) JSRuntime { return NewModerncJSRuntime(options) })
 }
 
 // ModerncJSRuntime wraps modernc.org/quickjs (pure Go port) for pooled usage
 type ModerncJSRuntime struct {
-	vm *quickjs.VM
+	vm        *quickjs.VM
+	heapLimit uint64
+	tls       *libc.TLS
 }
 
 // NewModerncJSRuntime creates a new pure Go QuickJS runtime
-func NewModerncJSRuntime() *ModerncJSRuntime {
+// Executions exceeding options.HeapLimit fail with an out of memory error.
+func NewModerncJSRuntime(options RuntimeOptions) *ModerncJSRuntime {
+	m := &ModerncJSRuntime{heapLimit: options.HeapLimit, tls: libc.NewTLS()}
+	if m.heapLimit == 0 {
+		m.heapLimit = quickJSHeapLimit
+	}
+	m.vm = m.newVM()
+	return m
+}
+
+// newVM creates a VM with the runtime's settings
+func (m *ModerncJSRuntime) newVM() *quickjs.VM {
 	vm, err := quickjs.NewVM()
 	if err != nil {
 		panic("failed to create modernc quickjs VM: " + err.Error())
 	}
 	// Configure VM settings
-	vm.SetMemoryLimit(256 * 1024 * 1024) // 256MB limit
-	vm.SetGCThreshold(0)                  // Disable automatic GC (0 = disabled)
-	return &ModerncJSRuntime{
-		vm: vm,
+	vm.SetMemoryLimit(uintptr(m.heapLimit)) // 256MB limit by default
+	vm.SetGCThreshold(0)                    // Disable automatic GC (0 = disabled)
+	if _, err := vm.Eval(uncaughtStackScript, quickjs.EvalGlobal); err != nil {
+		panic("failed to set up modernc quickjs VM: " + err.Error())
 	}
+	return vm
 }
 
+// uncaughtStackScript makes uncaught errors carry their stack.
+// modernc only reports the exception's string value, so Error.prototype.toString appends the stack when it is
+// called by the engine itself, with no script frame below it. String(error) in scripts is unchanged.
+const uncaughtStackScript = `(function(){var t=Error.prototype.toString;Object.defineProperty(Error.prototype,"toString",{value:function toString(){var s=t.call(this);if(typeof this.stack==="string"&&new Error().stack.split("\n").length<=2)s+="\n"+this.stack;return s},writable:true,configurable:true})})()`
+
 // Execute runs JavaScript code and returns the result
 func (m *ModerncJSRuntime) Execute(code string) (string, error) {
 	res, err := m.vm.Eval(code, quickjs.EvalGlobal)
@@ -43,8 +63,9 @@ func (m *ModerncJSRuntime) Execute(code string) (string, error) {
 		return "", fmt.Errorf("JS execution error: %w", err)
 	}
 
+	// null is returned as nil
 	if res == nil {
-		return "", nil
+		return "null", nil
 	}
 
 	// Convert result to string
@@ -54,23 +75,47 @@ func (m *ModerncJSRuntime) Execute(code string) (string, error) {
 	case int64:
 		return fmt.Sprintf("%d", v), nil
 	case float64:
-		return fmt.Sprintf("%f", v), nil
+		return formatNumber(v), nil
 	case bool:
 		if v {
 			return "true", nil
 		}
 		return "false", nil
 	case quickjs.Undefined:
-		return "", nil
+		return "undefined", nil
 	default:
 		return fmt.Sprintf("%v", v), nil
 	}
 }
 
-// ExecuteWithProps runs bundle with props (no bytecode caching, just concatenate)
+// formatNumber formats v like JavaScript's Number.prototype.toString
+func formatNumber(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	case v == 0:
+		return "0" // Including -0
+	}
+	// Exponential notation is only used outside of 1e-6 <= |v| < 1e21
+	if abs := math.Abs(v); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(v, 'e', -1, 64), "e")
+	sign, digits := exponent[:1], strings.TrimLeft(exponent[1:], "0")
+	return mantissa + "e" + sign + digits
+}
+
+// ExecuteWithProps runs bundle with props (no bytecode caching)
+// Props are evaluated separately so positions in the bundle match its source map
 func (m *ModerncJSRuntime) ExecuteWithProps(bundle, propsJSON string) (string, error) {
-	code := "var props = " + propsJSON + "; " + bundle
-	return m.Execute(code)
+	if _, err := m.vm.Eval("var props = "+propsJSON+";", quickjs.EvalGlobal); err != nil {
+		return "", fmt.Errorf("props error: %w", err)
+	}
+	return m.Execute(bundle)
 }
 
 // Reset prepares the runtime for reuse
@@ -79,13 +124,21 @@ func (m *ModerncJSRuntime) Reset() {
 	if m.vm != nil {
 		m.vm.Close()
 	}
-	vm, err := quickjs.NewVM()
-	if err != nil {
-		panic("failed to create modernc quickjs VM: " + err.Error())
-	}
-	vm.SetMemoryLimit(256 * 1024 * 1024)
-	vm.SetGCThreshold(0)
-	m.vm = vm
+	m.vm = m.newVM()
+}
+
+// HeapStats returns the memory allocated by the VM and its memory limit
+// modernc.org/quickjs doesn't expose the memory usage, so it is computed on the JSContext the VM starts with.
+func (m *ModerncJSRuntime) HeapStats() HeapStats {
+	context := *(*uintptr)(unsafe.Pointer(m.vm))
+	// The C code only writes memory of its own allocator, the usage is copied out of it
+	var usage lib.TJSMemoryUsage
+	size := int(unsafe.Sizeof(usage))
+	usagePtr := m.tls.Alloc(size)
+	defer m.tls.Free(size)
+	lib.XJS_ComputeMemoryUsage(m.tls, lib.XJS_GetRuntime(m.tls, context), usagePtr)
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(&usage)), size), libc.GoBytes(usagePtr, size))
+	return HeapStats{Used: uint64(usage.Fmalloc_size), Limit: uint64(usage.Fmalloc_limit)}
 }
 
 // Destroy permanently destroys the runtime
@@ -94,4 +147,8 @@ func (m *ModerncJSRuntime) Destroy() {
 		m.vm.Close()
 		m.vm = nil
 	}
+	if m.tls != nil {
+		m.tls.Close()
+		m.tls = nil
+	}
 }
@@ -2,80 +2,174 @@
 
 package jsruntime
 
+/*
+#include <stdint.h>
+
+// Declarations from quickjs.h, the library is linked by quickjs-go
+typedef struct JSRuntime JSRuntime;
+typedef struct JSMemoryUsage {
+	int64_t malloc_size, malloc_limit, memory_used_size;
+	int64_t malloc_count;
+	int64_t memory_used_count;
+	int64_t atom_count, atom_size;
+	int64_t str_count, str_size;
+	int64_t obj_count, obj_size;
+	int64_t prop_count, prop_size;
+	int64_t shape_count, shape_size;
+	int64_t js_func_count, js_func_size, js_func_code_size;
+	int64_t js_func_pc2line_count, js_func_pc2line_size;
+	int64_t c_func_count, array_count;
+	int64_t fast_array_count, fast_array_elements;
+	int64_t binary_object_count, binary_object_size;
+} JSMemoryUsage;
+void JS_ComputeMemoryUsage(JSRuntime *rt, JSMemoryUsage *s);
+*/
+import "C"
+
 import (
+	"errors"
+	"fmt"
+	"runtime"
+	"unsafe"
+
 	"github.com/buke/quickjs-go"
 )
 
 func init() {
-	defaultRuntimeType = RuntimeQuickJS
-}
-
-// newRuntime creates the default runtime for this build
-func newRuntime() JSRuntime {
-	return NewQuickJSRuntime()
+	registerRuntime(RuntimeQuickJS, func(options RuntimeOptions) JSRuntime { return NewQuickJSRuntime(options) })
 }
 
 // QuickJSRuntime wraps QuickJS for pooled usage
+// QuickJS checks stack overflows against the stack of the thread that created the runtime, so the runtime lives on a
+// thread of its own and every call runs there, whichever goroutine of the pool makes it.
 type QuickJSRuntime struct {
 	runtime *quickjs.Runtime
 	context *quickjs.Context
+	calls   chan func()
 }
 
 // NewQuickJSRuntime creates a new QuickJS runtime with optimized GC settings
-func NewQuickJSRuntime() *QuickJSRuntime {
-	// Disable automatic GC to prevent mid-request spikes
-	// GC will be triggered manually during Reset()
-	rt := quickjs.NewRuntime(
-		quickjs.WithGCThreshold(-1),        // Disable automatic GC
-		quickjs.WithMemoryLimit(256*1024*1024), // 256MB limit per runtime
-		quickjs.WithMaxStackSize(1024*1024),    // 1MB stack
-	)
-	ctx := rt.NewContext()
-	return &QuickJSRuntime{
-		runtime: rt,
-		context: ctx,
+// Executions exceeding options.HeapLimit fail with an out of memory error.
+func NewQuickJSRuntime(options RuntimeOptions) *QuickJSRuntime {
+	heapLimit := options.HeapLimit
+	if heapLimit == 0 {
+		heapLimit = quickJSHeapLimit
+	}
+	q := &QuickJSRuntime{calls: make(chan func())}
+	go q.run()
+	q.do(func() {
+		// Disable automatic GC to prevent mid-request spikes
+		// GC will be triggered manually during Reset()
+		q.runtime = quickjs.NewRuntime(
+			quickjs.WithGCThreshold(-1),         // Disable automatic GC
+			quickjs.WithMemoryLimit(heapLimit),  // 256MB limit per runtime by default
+			quickjs.WithMaxStackSize(1024*1024), // 1MB stack
+		)
+		q.context = q.runtime.NewContext()
+	})
+	return q
+}
+
+// run runs the calls to the runtime until it is destroyed, locked to the thread that creates the runtime
+func (q *QuickJSRuntime) run() {
+	runtime.LockOSThread()
+	for call := range q.calls {
+		call()
+	}
+}
+
+// do runs call on the runtime's thread and waits for it to return
+func (q *QuickJSRuntime) do(call func()) {
+	done := make(chan struct{})
+	q.calls <- func() {
+		defer close(done)
+		call()
 	}
+	<-done
 }
 
 // Execute runs JavaScript code and returns the result
-func (q *QuickJSRuntime) Execute(code string) (string, error) {
-	res := q.context.Eval(code)
+func (q *QuickJSRuntime) Execute(code string) (result string, err error) {
+	q.do(func() { result, err = q.eval(code, "render.js") })
+	return result, err
+}
+
+// ExecuteWithProps runs bundle with props (QuickJS doesn't have UnboundScript, so props are evaluated first)
+// Props are evaluated separately so positions in bundle.js match its source map
+func (q *QuickJSRuntime) ExecuteWithProps(bundle, propsJSON string) (result string, err error) {
+	q.do(func() {
+		if _, err = q.eval("var props = "+propsJSON+";", "props.js"); err != nil {
+			err = fmt.Errorf("props error: %w", err)
+			return
+		}
+		result, err = q.eval(bundle, "bundle.js")
+	})
+	return result, err
+}
+
+// eval runs code under the given script name and includes the JS stack trace in errors
+func (q *QuickJSRuntime) eval(code, fileName string) (string, error) {
+	res := q.context.Eval(code, quickjs.EvalFileName(fileName))
 	defer res.Free()
 
 	if res.IsException() {
-		return "", res.Error()
+		// The thrown value is held by the context, res only marks the exception
+		err := q.context.Exception()
+		if err == nil {
+			return "", errors.New("uncaught exception")
+		}
+		var jsErr *quickjs.Error
+		if errors.As(err, &jsErr) && jsErr.Stack != "" {
+			return "", fmt.Errorf("%s\n%s", jsErr.Error(), jsErr.Stack)
+		}
+		return "", err
 	}
 
 	return res.String(), nil
 }
 
-// ExecuteWithProps runs bundle with props (QuickJS doesn't have UnboundScript, so just concatenate)
-func (q *QuickJSRuntime) ExecuteWithProps(bundle, propsJSON string) (string, error) {
-	code := "var props = " + propsJSON + "; " + bundle
-	return q.Execute(code)
-}
-
 // Reset prepares the runtime for reuse
 // QuickJS contexts can accumulate state, so we recreate the context
 func (q *QuickJSRuntime) Reset() {
-	// Close old context - this frees most resources via reference counting
-	// QuickJS uses reference counting, so explicit GC is not needed here
-	if q.context != nil {
-		q.context.Close()
-	}
-	// Create new context for next request
-	// Note: GC is disabled (-1 threshold), memory is managed via refcount
-	q.context = q.runtime.NewContext()
+	q.do(func() {
+		// Close old context - this frees most resources via reference counting
+		// QuickJS uses reference counting, so explicit GC is not needed here
+		if q.context != nil {
+			q.context.Close()
+		}
+		// Create new context for next request
+		// Note: GC is disabled (-1 threshold), memory is managed via refcount
+		q.context = q.runtime.NewContext()
+	})
+}
+
+// HeapStats returns the memory allocated by the runtime and its memory limit
+// quickjs-go doesn't expose the memory usage, so it is computed on the JSRuntime its Runtime starts with.
+func (q *QuickJSRuntime) HeapStats() (stats HeapStats) {
+	q.do(func() {
+		var usage C.JSMemoryUsage
+		C.JS_ComputeMemoryUsage(*(**C.JSRuntime)(unsafe.Pointer(q.runtime)), &usage)
+		stats = HeapStats{Used: uint64(usage.malloc_size), Limit: uint64(usage.malloc_limit)}
+	})
+	return stats
 }
 
 // Destroy permanently destroys the runtime
+// The thread of the runtime exits with it.
 func (q *QuickJSRuntime) Destroy() {
-	if q.context != nil {
-		q.context.Close()
-		q.context = nil
-	}
-	if q.runtime != nil {
-		q.runtime.Close()
-		q.runtime = nil
+	if q.calls == nil {
+		return
 	}
+	q.do(func() {
+		if q.context != nil {
+			q.context.Close()
+			q.context = nil
+		}
+		if q.runtime != nil {
+			q.runtime.Close()
+			q.runtime = nil
+		}
+	})
+	close(q.calls)
+	q.calls = nil
 }
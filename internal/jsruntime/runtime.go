@@ -1,6 +1,18 @@
 package jsruntime
 
-import "sync"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
 // RuntimeType represents the type of JavaScript runtime
 type RuntimeType string
@@ -9,14 +21,43 @@ const (
 	RuntimeQuickJS   RuntimeType = "quickjs"
 	RuntimeV8        RuntimeType = "v8"
 	RuntimeModerncJS RuntimeType = "moderncjs"
+	RuntimeGoja      RuntimeType = "goja"
+	RuntimeDukgo     RuntimeType = "dukgo"
 )
 
-// defaultRuntimeType is set by init() in the build-specific files
-var defaultRuntimeType RuntimeType
+// runtimeBuildTags are the build tags compiling each runtime in
+var runtimeBuildTags = map[RuntimeType]string{
+	RuntimeV8:        "use_v8",
+	RuntimeQuickJS:   "use_quickjs",
+	RuntimeModerncJS: "use_moderncjs",
+	RuntimeGoja:      "use_goja",
+	RuntimeDukgo:     "use_dukgo",
+}
+
+// runtimePreference is the order in which the compiled-in runtimes are picked as the default, fastest first
+var runtimePreference = []RuntimeType{RuntimeV8, RuntimeQuickJS, RuntimeGoja, RuntimeModerncJS, RuntimeDukgo}
+
+// runtimes holds the constructors of the runtimes compiled in, registered by init() in the build-specific files
+var runtimes = map[RuntimeType]func(RuntimeOptions) JSRuntime{}
+
+// esTargets holds the newest ECMAScript version of the runtimes that don't support the latest one, e.g. "es5"
+var esTargets = map[RuntimeType]string{}
+
+// untrackedGlobals holds the runtimes whose declared globals can't be told apart from the assigned ones
+// Pools don't track the globals assigned by their executions.
+var untrackedGlobals = map[RuntimeType]bool{}
+
+// ErrRuntimeNotAvailable is returned when the requested runtime wasn't compiled in
+var ErrRuntimeNotAvailable = errors.New("JS runtime not available in this build")
+
+// registerRuntime makes a runtime compiled into this build available
+func registerRuntime(runtimeType RuntimeType, newRuntime func(RuntimeOptions) JSRuntime) {
+	runtimes[runtimeType] = newRuntime
+}
 
 // JSRuntime is the interface for JavaScript execution
 type JSRuntime interface {
-	// Execute runs JavaScript code and returns the result as a string
+	// Execute runs JavaScript code and returns the result as a string, formatted like String(result) as with V8
 	Execute(code string) (string, error)
 	// ExecuteWithProps runs a cached bundle with props injected
 	// The bundle is compiled once and cached; only props change per request
@@ -27,114 +68,554 @@ type JSRuntime interface {
 	Destroy()
 }
 
+// RuntimeOptions configures the runtimes created by NewRuntime and pools
+type RuntimeOptions struct {
+	HeapLimit    uint64    // Maximum heap size in bytes, the runtime's default if 0. Ignored by goja and dukgo.
+	FreshContext bool      // Reset creates a new context every time, only V8 reuses its context otherwise
+	CodeCache    CodeCache // Compiled code of bundles shared with other runtimes, only used by V8
+}
+
+// Isolation is how the global state left by an execution is kept from the next executions on the same runtime
+type Isolation string
+
+const (
+	// IsolationNone reuses the global scope, V8 only recreates its context every 1000 executions
+	IsolationNone Isolation = ""
+	// IsolationGlobals deletes the globals assigned by each execution
+	// Declared globals and changes to existing globals are kept.
+	IsolationGlobals Isolation = "globals"
+	// IsolationContext runs every execution in a new context
+	IsolationContext Isolation = "context"
+)
+
+// quickJSHeapLimit is the heap limit of the QuickJS runtimes when RuntimeOptions.HeapLimit is 0
+const quickJSHeapLimit = 256 * 1024 * 1024
+
+// HeapStats is the heap usage reported by a HeapReporter
+type HeapStats struct {
+	Used  uint64 // Bytes used by the heap
+	Limit uint64 // Bytes the heap can grow to
+}
+
+// Compiler is implemented by runtimes caching compiled bundles, which can compile them ahead of execution
+type Compiler interface {
+	Compile(bundle string) error
+}
+
+// HeapReporter is implemented by runtimes that report their heap usage
+// Pools use it to recycle runtimes before they run out of memory.
+type HeapReporter interface {
+	HeapStats() HeapStats
+}
+
+// hashBundle creates a short hash of the bundle for cache lookup
+func hashBundle(bundle string) string {
+	h := sha256.Sum256([]byte(bundle))
+	return hex.EncodeToString(h[:8]) // First 8 bytes = 16 hex chars
+}
+
 // Pool manages a pool of JS runtimes for reuse
+// It keeps minSize runtimes and grows on demand up to maxSize, destroying the extra runtimes once idle.
 type Pool struct {
 	runtimeType RuntimeType
-	pool        chan JSRuntime
+	newRuntime  func() JSRuntime
 	maxSize     int
 	created     int
 	closed      bool
 	mu          sync.Mutex
 
-	// Track all created runtimes for proper cleanup
-	allRuntimes []JSRuntime
-	runtimesMu  sync.Mutex
+	minSize     int
+	idleTimeout time.Duration
+	maxQueue    int
+	idle        []idleRuntime    // Most recently used last, so runtimes beyond minSize go idle under light load
+	waiters     []chan JSRuntime // Callers waiting for a runtime, first come first served
+	stopEvict   chan struct{}
+
+	// createMu serializes runtime creation, concurrent v8go Isolate creation causes crashes
+	createMu sync.Mutex
+
+	heapRecycleRatio float64
+
+	isolation    Isolation
+	onGlobalLeak func(names []string)
+
+	// Counters reported by Stats
+	totalCreated int
+	saturated    int
+	recycled     map[string]int // By reason
+}
+
+// Reasons runtimes are recycled, reported by Pool.Stats
+const (
+	RecycleError = "error" // The last execution failed
+	RecycleHeap  = "heap"  // The heap grew beyond the recycle ratio of its limit, or ran out of memory
+	RecycleIdle  = "idle"  // The runtime was idle beyond the minimum pool size for IdleTimeout
+)
+
+// idleRuntime is a runtime waiting in the pool
+type idleRuntime struct {
+	runtime JSRuntime
+	since   time.Time
 }
 
 // PoolConfig configures the runtime pool
 type PoolConfig struct {
 	RuntimeType RuntimeType
 	PoolSize    int // Maximum number of runtimes to keep in pool
+
+	MinSize     int           // Runtimes created upfront and kept when idle, PoolSize by default
+	IdleTimeout time.Duration // Idle runtimes beyond MinSize are destroyed after this long, never if 0
+	MaxQueue    int           // Callers allowed to wait for a runtime before ErrPoolSaturated is returned, unlimited if 0
+
+	HeapLimit        uint64  // Maximum heap size of each runtime in bytes, the runtime's default if 0
+	HeapRecycleRatio float64 // Runtimes using more than this fraction of their heap limit after an execution are recycled, 0.8 by default
+
+	Isolation Isolation // How the global state of an execution is kept from the next ones, IsolationNone by default
+	// OnGlobalLeak is called with the names of the globals an execution assigned, which are seen by the next
+	// executions unless Isolation deletes them, e.g. to warn about user data leaking between renders
+	OnGlobalLeak func(names []string)
+
+	// CodeCache persists the code compiled by the runtimes, e.g. on disk so restarts skip compilation.
+	// The runtimes of the pool also share the code in memory unless SkipMemoryCodeCache is set.
+	CodeCache CodeCache
+	// SkipMemoryCodeCache doesn't share compiled code in memory, e.g. in development where every edit changes bundles
+	SkipMemoryCodeCache bool
 }
 
-// DefaultRuntimeType returns the runtime type for this build
+// ErrPoolSaturated is returned when all runtimes are busy and MaxQueue callers are already waiting
+var ErrPoolSaturated = errors.New("JS runtime pool saturated")
+
+// ErrPoolClosed is returned when getting a runtime from a closed pool
+var ErrPoolClosed = errors.New("JS runtime pool closed")
+
+// DefaultRuntimeType returns the runtime type used when none is configured, the fastest one compiled in
 func DefaultRuntimeType() RuntimeType {
-	return defaultRuntimeType
+	for _, runtimeType := range runtimePreference {
+		if _, ok := runtimes[runtimeType]; ok {
+			return runtimeType
+		}
+	}
+	return ""
+}
+
+// AvailableRuntimes returns the runtime types compiled into this build
+func AvailableRuntimes() []RuntimeType {
+	available := make([]RuntimeType, 0, len(runtimes))
+	for runtimeType := range runtimes {
+		available = append(available, runtimeType)
+	}
+	sort.Slice(available, func(i, j int) bool {
+		return preferenceIndex(available[i]) < preferenceIndex(available[j])
+	})
+	return available
+}
+
+// preferenceIndex returns the position of a runtime type in runtimePreference, unknown types last
+func preferenceIndex(runtimeType RuntimeType) int {
+	for i, preferred := range runtimePreference {
+		if preferred == runtimeType {
+			return i
+		}
+	}
+	return len(runtimePreference)
+}
+
+// ESTarget returns the newest ECMAScript version supported by a runtime, empty for the latest
+// Server bundles are lowered to it.
+func ESTarget(runtimeType RuntimeType) string {
+	return esTargets[runtimeType]
+}
+
+// NewRuntime creates a standalone runtime of the given type, or of the default type if empty
+func NewRuntime(runtimeType RuntimeType) (JSRuntime, error) {
+	newRuntime, err := runtimeConstructor(runtimeType)
+	if err != nil {
+		return nil, err
+	}
+	return newRuntime(RuntimeOptions{}), nil
+}
+
+// runtimeConstructor returns the constructor of a compiled-in runtime
+func runtimeConstructor(runtimeType RuntimeType) (func(RuntimeOptions) JSRuntime, error) {
+	if runtimeType == "" {
+		runtimeType = DefaultRuntimeType()
+	}
+	if newRuntime, ok := runtimes[runtimeType]; ok {
+		return newRuntime, nil
+	}
+	tag, known := runtimeBuildTags[runtimeType]
+	if !known {
+		return nil, fmt.Errorf("%w: unknown runtime %q, compiled in: %v", ErrRuntimeNotAvailable, runtimeType, AvailableRuntimes())
+	}
+	return nil, fmt.Errorf("%w: %s isn't compiled in (compiled in: %v), build with -tags %s", ErrRuntimeNotAvailable, runtimeType, AvailableRuntimes(), tag)
 }
 
 // NewPool creates a new runtime pool
-func NewPool(config PoolConfig) *Pool {
+// It returns ErrRuntimeNotAvailable if config.RuntimeType wasn't compiled in
+func NewPool(config PoolConfig) (*Pool, error) {
 	if config.PoolSize <= 0 {
 		config.PoolSize = 10
 	}
+	if config.MinSize <= 0 || config.MinSize > config.PoolSize {
+		config.MinSize = config.PoolSize
+	}
+	if config.HeapRecycleRatio <= 0 {
+		config.HeapRecycleRatio = 0.8
+	}
 	// Use default runtime type if not specified
 	if config.RuntimeType == "" {
-		config.RuntimeType = defaultRuntimeType
+		config.RuntimeType = DefaultRuntimeType()
+	}
+	switch config.Isolation {
+	case IsolationNone, IsolationGlobals, IsolationContext:
+	default:
+		return nil, fmt.Errorf("unknown isolation %q", config.Isolation)
+	}
+	newRuntime, err := runtimeConstructor(config.RuntimeType)
+	if err != nil {
+		return nil, err
+	}
+	codeCache := config.CodeCache
+	if !config.SkipMemoryCodeCache {
+		codeCache = NewMemoryCodeCache(codeCache)
+	}
+	options := RuntimeOptions{
+		HeapLimit:    config.HeapLimit,
+		FreshContext: config.Isolation == IsolationContext,
+		CodeCache:    codeCache,
 	}
 
 	p := &Pool{
 		runtimeType: config.RuntimeType,
+		newRuntime: func() JSRuntime {
+			return newRuntime(options)
+		},
 		maxSize:     config.PoolSize,
-		pool:        make(chan JSRuntime, config.PoolSize),
-		allRuntimes: make([]JSRuntime, 0, config.PoolSize),
+		minSize:     config.MinSize,
+		idleTimeout: config.IdleTimeout,
+		maxQueue:    config.MaxQueue,
+		idle:        make([]idleRuntime, 0, config.PoolSize),
+		stopEvict:   make(chan struct{}),
+
+		heapRecycleRatio: config.HeapRecycleRatio,
+		recycled:         map[string]int{},
+
+		isolation:    config.Isolation,
+		onGlobalLeak: config.OnGlobalLeak,
 	}
 
 	// Pre-warm the pool
-	for i := 0; i < config.PoolSize; i++ {
-		rt := p.createRuntime()
-		p.pool <- rt
+	p.created = config.MinSize
+	for i := 0; i < config.MinSize; i++ {
+		p.idle = append(p.idle, idleRuntime{p.createRuntime(), time.Now()})
 	}
 
-	return p
+	if config.IdleTimeout > 0 && config.MinSize < config.PoolSize {
+		go p.evictIdle()
+	}
+
+	return p, nil
 }
 
-// createRuntime creates a new runtime and tracks it
+// createRuntime creates a new runtime, the caller must have counted it in p.created
 func (p *Pool) createRuntime() JSRuntime {
+	p.createMu.Lock()
+	defer p.createMu.Unlock()
+	rt := p.newRuntime()
+
 	p.mu.Lock()
-	p.created++
+	p.totalCreated++
 	p.mu.Unlock()
-
-	rt := newRuntime()
-
-	// Track for cleanup
-	p.runtimesMu.Lock()
-	p.allRuntimes = append(p.allRuntimes, rt)
-	p.runtimesMu.Unlock()
-
 	return rt
 }
 
 // Get retrieves a runtime from the pool
-// Blocks if pool is empty - never creates additional runtimes beyond pool size
-// This is critical for v8go safety: concurrent Isolate creation causes crashes
+// Blocks until a runtime is available, without the MaxQueue limit. It returns nil if the pool is closed.
 func (p *Pool) Get() JSRuntime {
-	// Blocking read - wait for available runtime
-	return <-p.pool
+	rt, _ := p.get(context.Background(), false)
+	return rt
+}
+
+// GetContext retrieves a runtime from the pool, creating one if all are busy and the pool is below its maximum size
+// It waits until a runtime is returned or ctx is done, and returns ErrPoolSaturated without waiting if
+// MaxQueue callers are already waiting.
+func (p *Pool) GetContext(ctx context.Context) (JSRuntime, error) {
+	return p.get(ctx, true)
+}
+
+func (p *Pool) get(ctx context.Context, limitQueue bool) (JSRuntime, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	if n := len(p.idle); n > 0 {
+		rt := p.idle[n-1].runtime
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return rt, nil
+	}
+	if p.created < p.maxSize {
+		p.created++
+		p.mu.Unlock()
+		return p.createRuntime(), nil
+	}
+	if limitQueue && p.maxQueue > 0 && len(p.waiters) >= p.maxQueue {
+		p.saturated++
+		p.mu.Unlock()
+		return nil, ErrPoolSaturated
+	}
+	waiter := make(chan JSRuntime, 1)
+	p.waiters = append(p.waiters, waiter)
+	p.mu.Unlock()
+
+	select {
+	case rt, ok := <-waiter:
+		if !ok {
+			return nil, ErrPoolClosed
+		}
+		return rt, nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		for i, w := range p.waiters {
+			if w == waiter {
+				p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+				break
+			}
+		}
+		p.mu.Unlock()
+		// A runtime may have been handed over before the waiter was removed
+		select {
+		case rt, ok := <-waiter:
+			if ok {
+				p.release(rt)
+			}
+		default:
+		}
+		return nil, ctx.Err()
+	}
 }
 
 // Put returns a runtime to the pool
 func (p *Pool) Put(rt JSRuntime) {
+	rt.Reset()
+	p.release(rt)
+}
+
+// release hands a reset runtime to the first waiting caller, or keeps it idle
+func (p *Pool) release(rt JSRuntime) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		rt.Destroy()
+		return
+	}
+	if len(p.waiters) > 0 {
+		waiter := p.waiters[0]
+		p.waiters = p.waiters[1:]
+		waiter <- rt
+	} else {
+		p.idle = append(p.idle, idleRuntime{rt, time.Now()})
+	}
+	p.mu.Unlock()
+}
+
+// recycle destroys a runtime that may be in a broken state or use too much memory
+// It is replaced right away if the pool is below its minimum size or callers are waiting.
+func (p *Pool) recycle(rt JSRuntime, reason string) {
+	rt.Destroy()
+
 	p.mu.Lock()
-	closed := p.closed
+	p.created--
+	p.recycled[reason]++
+	replace := !p.closed && (p.created < p.minSize || len(p.waiters) > 0)
+	if replace {
+		p.created++
+	}
 	p.mu.Unlock()
 
-	if closed {
-		rt.Destroy()
+	if replace {
+		p.release(p.createRuntime())
+	}
+}
+
+// putAfter returns a runtime to the pool after an execution, recycling it if the execution failed or its heap is
+// close to the limit
+func (p *Pool) putAfter(rt JSRuntime, err error) {
+	if reason := p.recycleReason(rt, err); reason != "" {
+		p.recycle(rt, reason)
 		return
 	}
+	p.Put(rt)
+}
 
-	rt.Reset()
-	select {
-	case p.pool <- rt:
-		// Successfully returned to pool
-	default:
-		// Pool is full, destroy the runtime
-		rt.Destroy()
+// recycleReason returns why a runtime must be recycled after an execution, empty if it can be reused
+func (p *Pool) recycleReason(rt JSRuntime, err error) string {
+	if reporter, ok := rt.(HeapReporter); ok {
+		stats := reporter.HeapStats()
+		if stats.Limit > 0 && float64(stats.Used) > p.heapRecycleRatio*float64(stats.Limit) {
+			return RecycleHeap
+		}
+	}
+	if err == nil {
+		return ""
+	}
+	// QuickJS reports allocations beyond its memory limit as "InternalError: out of memory", V8 terminates the
+	// execution near its heap limit and raises the limit to let it unwind
+	if strings.Contains(err.Error(), "out of memory") || strings.Contains(err.Error(), "ExecutionTerminated") {
+		return RecycleHeap
+	}
+	return RecycleError
+}
+
+// evictIdle periodically destroys the runtimes beyond minSize that have been idle for idleTimeout
+func (p *Pool) evictIdle() {
+	// Tiny timeouts are checked every millisecond, NewTicker panics on a zero interval
+	ticker := time.NewTicker(max(p.idleTimeout/2, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopEvict:
+			return
+		case now := <-ticker.C:
+			for _, rt := range p.takeExpired(now) {
+				rt.Destroy()
+			}
+		}
 	}
 }
 
+// takeExpired removes the runtimes beyond minSize idle since before now - idleTimeout from the pool
+func (p *Pool) takeExpired(now time.Time) []JSRuntime {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var expired []JSRuntime
+	// The least recently used runtimes come first
+	for len(p.idle) > 0 && p.created > p.minSize && now.Sub(p.idle[0].since) >= p.idleTimeout {
+		expired = append(expired, p.idle[0].runtime)
+		p.idle = p.idle[1:]
+		p.created--
+		p.recycled[RecycleIdle]++
+	}
+	return expired
+}
+
+// Precompile compiles a bundle in the idle runtimes, so their first ExecuteWithProps of it skips compilation
+// Runtimes created later compile it from the code cache. Runtimes that don't implement Compiler are skipped.
+func (p *Pool) Precompile(bundle string) error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = make([]idleRuntime, 0, p.maxSize)
+	p.mu.Unlock()
+
+	var err error
+	for _, entry := range idle {
+		if compiler, ok := entry.runtime.(Compiler); ok && err == nil {
+			err = compiler.Compile(bundle)
+		}
+		p.release(entry.runtime)
+	}
+	return err
+}
+
 // Execute is a convenience method that gets a runtime, executes code, and returns it
+// The runtime is destroyed instead if the execution fails.
 func (p *Pool) Execute(code string) (string, error) {
-	rt := p.Get()
-	defer p.Put(rt)
-	return rt.Execute(code)
+	return p.execute(func(rt JSRuntime) (string, error) {
+		return rt.Execute(code)
+	})
 }
 
 // ExecuteWithProps executes a cached bundle with props
+// The runtime is destroyed instead if the execution fails.
 func (p *Pool) ExecuteWithProps(bundle, propsJSON string) (string, error) {
-	rt := p.Get()
-	defer p.Put(rt)
-	return rt.ExecuteWithProps(bundle, propsJSON)
+	return p.execute(func(rt JSRuntime) (string, error) {
+		return rt.ExecuteWithProps(bundle, propsJSON)
+	})
+}
+
+// execute runs an execution on a runtime of the pool, tracking the globals it assigns if needed
+func (p *Pool) execute(run func(rt JSRuntime) (string, error)) (string, error) {
+	rt, err := p.GetContext(context.Background())
+	if err != nil {
+		return "", err
+	}
+	var before []string
+	trackGlobals := (p.isolation == IsolationGlobals || p.onGlobalLeak != nil) && !untrackedGlobals[p.runtimeType]
+	if trackGlobals {
+		before, err = assignedGlobals(rt)
+	}
+	result := ""
+	if err == nil {
+		result, err = run(rt)
+	}
+	if trackGlobals && err == nil {
+		err = p.checkGlobals(rt, before)
+	}
+	p.putAfter(rt, err)
+	return result, err
+}
+
+// assignedGlobalsScript lists the configurable globals as JSON, the ones assigned at runtime rather than declared
+// with var or function
+const assignedGlobalsScript = `JSON.stringify(Object.getOwnPropertyNames(globalThis).filter(function(name){var d=Object.getOwnPropertyDescriptor(globalThis,name);return d&&d.configurable}))`
+
+// bundleGlobals are the globals assigned by every server bundle, which are reset by each execution
+var bundleGlobals = map[string]bool{"__ssr_result": true, "__ssr_errors": true}
+
+// assignedGlobals returns the names of the globals of rt assigned at runtime, including the built-ins
+func assignedGlobals(rt JSRuntime) ([]string, error) {
+	result, err := rt.Execute(assignedGlobalsScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list globals: %w", err)
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(result), &names); err != nil {
+		return nil, fmt.Errorf("failed to list globals: %w", err)
+	}
+	return names, nil
+}
+
+// checkGlobals reports the globals assigned since before to onGlobalLeak and deletes them with IsolationGlobals
+func (p *Pool) checkGlobals(rt JSRuntime, before []string) error {
+	after, err := assignedGlobals(rt)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool, len(before))
+	for _, name := range before {
+		existing[name] = true
+	}
+	var leaked []string
+	for _, name := range after {
+		if !existing[name] && !bundleGlobals[name] {
+			leaked = append(leaked, name)
+		}
+	}
+	if len(leaked) == 0 {
+		return nil
+	}
+	sort.Strings(leaked)
+	if p.onGlobalLeak != nil {
+		p.onGlobalLeak(leaked)
+	}
+	if p.isolation == IsolationGlobals {
+		names, _ := json.Marshal(leaked)
+		if _, err := rt.Execute("(function(names){for(var i=0;i<names.length;i++){delete globalThis[names[i]]}})(" + string(names) + ")"); err != nil {
+			return fmt.Errorf("failed to delete globals: %w", err)
+		}
+	}
+	return nil
+}
+
+// RuntimeType returns the type of the runtimes of the pool
+func (p *Pool) RuntimeType() RuntimeType {
+	return p.runtimeType
+}
+
+// ESTarget returns the newest ECMAScript version supported by the runtimes of the pool, empty for the latest
+func (p *Pool) ESTarget() string {
+	return ESTarget(p.runtimeType)
 }
 
 // Stats returns pool statistics
@@ -143,14 +624,21 @@ func (p *Pool) Stats() map[string]interface{} {
 	defer p.mu.Unlock()
 	return map[string]interface{}{
 		"runtime_type":  p.runtimeType,
-		"total_created": p.created,
+		"total_created": p.totalCreated,
+		"size":          p.created,
+		"min_pool_size": p.minSize,
 		"max_pool_size": p.maxSize,
-		"pool_size":     len(p.pool),
+		"pool_size":     len(p.idle),
+		"in_use":        p.created - len(p.idle),
+		"waiting":       len(p.waiters),
+		"saturated":     p.saturated,
+		"recycled":      maps.Clone(p.recycled),
 		"closed":        p.closed,
 	}
 }
 
-// Close marks the pool as closed and destroys all runtimes
+// Close marks the pool as closed and destroys the idle runtimes
+// Runtimes in use are destroyed when they are returned.
 func (p *Pool) Close() {
 	p.mu.Lock()
 	if p.closed {
@@ -158,19 +646,16 @@ func (p *Pool) Close() {
 		return
 	}
 	p.closed = true
-	p.mu.Unlock()
-
-	// Drain the pool
-	close(p.pool)
-	for rt := range p.pool {
-		rt.Destroy()
+	idle := p.idle
+	p.idle = nil
+	for _, waiter := range p.waiters {
+		close(waiter)
 	}
+	p.waiters = nil
+	close(p.stopEvict)
+	p.mu.Unlock()
 
-	// Destroy any remaining tracked runtimes
-	p.runtimesMu.Lock()
-	for _, rt := range p.allRuntimes {
-		rt.Destroy()
+	for _, idle := range idle {
+		idle.runtime.Destroy()
 	}
-	p.allRuntimes = nil
-	p.runtimesMu.Unlock()
 }
@@ -0,0 +1,212 @@
+package jsruntime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPool_RuntimeType(t *testing.T) {
+	pool, err := NewPool(PoolConfig{PoolSize: 1})
+	assert.Nil(t, err, "The default runtime should be available")
+	assert.Equal(t, DefaultRuntimeType(), pool.RuntimeType(), "The default runtime should be used")
+	pool.Close()
+
+	for runtimeType := range runtimeBuildTags {
+		if _, ok := runtimes[runtimeType]; ok {
+			continue
+		}
+		_, err := NewPool(PoolConfig{RuntimeType: runtimeType, PoolSize: 1})
+		assert.ErrorIs(t, err, ErrRuntimeNotAvailable, "Runtimes that aren't compiled in should not be available")
+		assert.Contains(t, err.Error(), "-tags "+runtimeBuildTags[runtimeType], "The error should name the build tag")
+	}
+
+	_, err = NewPool(PoolConfig{RuntimeType: "rhino", PoolSize: 1})
+	assert.ErrorIs(t, err, ErrRuntimeNotAvailable, "Unknown runtimes should not be available")
+}
+
+func TestPool_Elastic(t *testing.T) {
+	pool, err := NewPool(PoolConfig{PoolSize: 2, MinSize: 1, MaxQueue: 1})
+	assert.Nil(t, err, "NewPool should not return an error")
+	defer pool.Close()
+	assert.Equal(t, 1, pool.Stats()["size"], "MinSize runtimes should be created upfront")
+
+	first, err := pool.GetContext(context.Background())
+	assert.Nil(t, err, "GetContext should return the idle runtime")
+	second, err := pool.GetContext(context.Background())
+	assert.Nil(t, err, "GetContext should grow the pool up to PoolSize")
+	assert.Equal(t, 2, pool.Stats()["size"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	waited := make(chan error)
+	go func() {
+		_, err := pool.GetContext(ctx)
+		waited <- err
+	}()
+	assert.Eventually(t, func() bool { return pool.Stats()["waiting"] == 1 }, time.Second, time.Millisecond)
+	_, err = pool.GetContext(context.Background())
+	assert.ErrorIs(t, err, ErrPoolSaturated, "GetContext should not wait beyond MaxQueue")
+	assert.ErrorIs(t, <-waited, context.DeadlineExceeded, "GetContext should stop waiting when ctx is done")
+
+	go func() {
+		rt, err := pool.GetContext(context.Background())
+		if err == nil {
+			pool.Put(rt)
+		}
+		waited <- err
+	}()
+	assert.Eventually(t, func() bool { return pool.Stats()["waiting"] == 1 }, time.Second, time.Millisecond)
+	pool.Put(first)
+	assert.Nil(t, <-waited, "Returned runtimes should be handed to waiting callers")
+	pool.Put(second)
+	assert.Equal(t, 2, pool.Stats()["pool_size"])
+}
+
+func TestPool_IdleTimeout(t *testing.T) {
+	pool, err := NewPool(PoolConfig{PoolSize: 3, MinSize: 1, IdleTimeout: 20 * time.Millisecond})
+	assert.Nil(t, err, "NewPool should not return an error")
+	defer pool.Close()
+
+	runtimes := []JSRuntime{pool.Get(), pool.Get(), pool.Get()}
+	for _, rt := range runtimes {
+		pool.Put(rt)
+	}
+	assert.Eventually(t, func() bool { return pool.Stats()["size"] == 1 }, time.Second, 5*time.Millisecond, "Idle runtimes beyond MinSize should be destroyed")
+	assert.Equal(t, 2, pool.Stats()["recycled"].(map[string]int)[RecycleIdle])
+
+	// Timeouts shorter than the ticker resolution shouldn't panic
+	tiny, err := NewPool(PoolConfig{PoolSize: 2, MinSize: 1, IdleTimeout: time.Nanosecond})
+	assert.Nil(t, err, "NewPool should not return an error")
+	defer tiny.Close()
+	tiny.Put(tiny.Get())
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestPool_DiscardFailed(t *testing.T) {
+	pool, err := NewPool(PoolConfig{PoolSize: 1})
+	assert.Nil(t, err, "NewPool should not return an error")
+	defer pool.Close()
+
+	_, err = pool.Execute("throw new Error('boom')")
+	assert.NotNil(t, err, "Execute should return the error")
+	stats := pool.Stats()
+	assert.Equal(t, map[string]int{RecycleError: 1}, stats["recycled"], "The runtime should be destroyed after a failed execution")
+	assert.Equal(t, 2, stats["total_created"], "The runtime should be replaced to keep MinSize runtimes")
+	assert.Equal(t, 1, stats["pool_size"])
+
+	result, err := pool.Execute(`"ok"`)
+	assert.Nil(t, err, "Execute should not return an error")
+	assert.Equal(t, "ok", result)
+
+	pool.Close()
+	_, err = pool.Execute(`"ok"`)
+	assert.ErrorIs(t, err, ErrPoolClosed, "Closed pools should not execute code")
+}
+
+// heapRuntime reports a heap of 100 bytes using used bytes
+type heapRuntime struct {
+	JSRuntime
+	used uint64
+}
+
+func (h heapRuntime) HeapStats() HeapStats {
+	return HeapStats{Used: h.used, Limit: 100}
+}
+
+func TestPool_RecycleHeap(t *testing.T) {
+	pool, err := NewPool(PoolConfig{PoolSize: 1, HeapLimit: 8 * 1024 * 1024})
+	assert.Nil(t, err, "NewPool should not return an error")
+	defer pool.Close()
+
+	rt := pool.Get()
+	pool.Put(rt)
+	assert.Equal(t, "", pool.recycleReason(heapRuntime{rt, 80}, nil), "Runtimes below the recycle ratio should be reused")
+	assert.Equal(t, RecycleHeap, pool.recycleReason(heapRuntime{rt, 81}, nil), "Runtimes above the recycle ratio should be recycled")
+	assert.Equal(t, RecycleError, pool.recycleReason(rt, errors.New("Error: boom")))
+	assert.Equal(t, RecycleHeap, pool.recycleReason(rt, errors.New("ExecutionTerminated: script execution has been terminated")), "V8 executions terminated near the heap limit should be recycled for the heap")
+
+	assert.Equal(t, RecycleHeap, pool.recycleReason(rt, errors.New("InternalError: out of memory")), "QuickJS executions out of memory should be recycled for the heap")
+}
+
+func TestPool_RecycleHeapBeforeLimit(t *testing.T) {
+	pool, err := NewPool(PoolConfig{PoolSize: 1, HeapLimit: 16 * 1024 * 1024, HeapRecycleRatio: 0.25})
+	assert.Nil(t, err, "NewPool should not return an error")
+	defer pool.Close()
+
+	rt := pool.Get()
+	reporter, ok := rt.(HeapReporter)
+	pool.Put(rt)
+	if pool.RuntimeType() == RuntimeGoja || pool.RuntimeType() == RuntimeDukgo {
+		assert.False(t, ok, "Runtimes without a heap limit have no heap to report")
+		return
+	}
+	assert.True(t, ok, "Runtimes with a heap limit should report their heap")
+	stats := reporter.HeapStats()
+	assert.Equal(t, uint64(16*1024*1024), stats.Limit, "The heap limit should be reported")
+	assert.True(t, stats.Used > 0 && stats.Used < stats.Limit/4, "A fresh runtime should use little of its heap, got %d", stats.Used)
+
+	// Keeping 8MB alive is within the limit but above the recycle threshold
+	_, err = pool.Execute("globalThis.kept = 'x'.repeat(8 << 20) + 'y'; kept.length")
+	assert.Nil(t, err, "Execute should not fail below the heap limit")
+	assert.Equal(t, map[string]int{RecycleHeap: 1}, pool.Stats()["recycled"], "Runtimes above the recycle threshold should be recycled")
+}
+
+func TestPool_Isolation(t *testing.T) {
+	var leaks [][]string
+	pool, err := NewPool(PoolConfig{
+		PoolSize:     1,
+		Isolation:    IsolationGlobals,
+		OnGlobalLeak: func(names []string) { leaks = append(leaks, names) },
+	})
+	assert.Nil(t, err, "NewPool should not return an error")
+	defer pool.Close()
+
+	code := `var declared = 1; globalThis.__ssr_result = typeof user; globalThis.user = "a"; store = {}; globalThis.__ssr_result`
+	for i := 0; i < 2; i++ {
+		result, err := pool.Execute(code)
+		assert.Nil(t, err, "Execute should not return an error")
+		assert.Equal(t, "undefined", result, "Assigned globals should be deleted after each execution")
+	}
+	if untrackedGlobals[pool.RuntimeType()] {
+		assert.Nil(t, leaks, "Globals should not be tracked")
+	} else {
+		assert.Equal(t, [][]string{{"store", "user"}, {"store", "user"}}, leaks, "Assigned globals should be reported")
+	}
+
+	_, err = NewPool(PoolConfig{PoolSize: 1, Isolation: "process"})
+	assert.EqualError(t, err, `unknown isolation "process"`)
+}
+
+// compilingRuntime records the bundles compiled ahead of execution
+type compilingRuntime struct {
+	JSRuntime
+	compiled []string
+}
+
+func (c *compilingRuntime) Compile(bundle string) error {
+	c.compiled = append(c.compiled, bundle)
+	return nil
+}
+
+func TestPool_Precompile(t *testing.T) {
+	pool, err := NewPool(PoolConfig{PoolSize: 2})
+	assert.Nil(t, err, "NewPool should not return an error")
+	defer pool.Close()
+
+	compilers := []*compilingRuntime{{JSRuntime: pool.idle[0].runtime}, {JSRuntime: pool.idle[1].runtime}}
+	pool.idle[0].runtime, pool.idle[1].runtime = compilers[0], compilers[1]
+
+	assert.Nil(t, pool.Precompile("1 + 1"), "Precompile should not return an error")
+	for _, compiler := range compilers {
+		assert.Equal(t, []string{"1 + 1"}, compiler.compiled, "Every idle runtime should compile the bundle")
+	}
+	assert.Equal(t, 2, pool.Stats()["pool_size"], "Runtimes should be returned to the pool")
+
+	result, err := pool.ExecuteWithProps("1 + 1", "null")
+	assert.Nil(t, err, "ExecuteWithProps should not return an error")
+	assert.Equal(t, "2", result)
+}
@@ -1,22 +1,15 @@
-//go:build !use_quickjs && !use_moderncjs
+//go:build use_v8 || !use_quickjs && !use_moderncjs && !use_goja && !use_dukgo
 
 package jsruntime
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 
 	v8 "github.com/tommie/v8go"
 )
 
 func init() {
-	defaultRuntimeType = RuntimeV8
-}
-
-// newRuntime creates the default runtime for this build
-func newRuntime() JSRuntime {
-	return NewV8Runtime()
+	registerRuntime(RuntimeV8, func(options RuntimeOptions) JSRuntime { return NewV8Runtime(options) })
 }
 
 // V8Runtime wraps V8 for pooled usage
@@ -26,26 +19,31 @@ type V8Runtime struct {
 	requestCount  int
 	maxRequests   int // Context is recreated after this many requests to prevent memory buildup
 	cachedScripts map[string]*v8.UnboundScript // hash -> compiled script
+	codeCache     CodeCache                    // Compiled code shared with the other isolates, may be nil
 }
 
 // NewV8Runtime creates a new V8 runtime
-func NewV8Runtime() *V8Runtime {
-	isolate := v8.NewIsolate()
+// Executions are terminated when the heap reaches options.HeapLimit.
+func NewV8Runtime(options RuntimeOptions) *V8Runtime {
+	var isolateOptions []v8.IsolateOption
+	if options.HeapLimit > 0 {
+		isolateOptions = append(isolateOptions, v8.WithResourceConstraints(0, options.HeapLimit))
+	}
+	isolate := v8.NewIsolate(isolateOptions...)
 	context := v8.NewContext(isolate)
+	maxRequests := 1000 // Recreate context every 1000 requests to prevent memory buildup
+	if options.FreshContext {
+		maxRequests = 1
+	}
 	return &V8Runtime{
 		isolate:       isolate,
 		context:       context,
-		maxRequests:   1000, // Recreate context every 1000 requests to prevent memory buildup
+		maxRequests:   maxRequests,
 		cachedScripts: make(map[string]*v8.UnboundScript),
+		codeCache:     options.CodeCache,
 	}
 }
 
-// hashBundle creates a short hash of the bundle for cache lookup
-func hashBundle(bundle string) string {
-	h := sha256.Sum256([]byte(bundle))
-	return hex.EncodeToString(h[:8]) // First 8 bytes = 16 hex chars
-}
-
 // Execute runs JavaScript code and returns the result
 func (v *V8Runtime) Execute(code string) (string, error) {
 	val, err := v.context.RunScript(code, "render.js")
@@ -78,20 +76,9 @@ func (v *V8Runtime) ExecuteWithProps(bundle, propsJSON string) (string, error) {
 	}
 
 	// 2. Get or compile cached bundle
-	hash := hashBundle(bundle)
-	script, ok := v.cachedScripts[hash]
-	if !ok {
-		var err error
-		script, err = v.isolate.CompileUnboundScript(bundle, "bundle.js", v8.CompileOptions{
-			Mode: v8.CompileModeEager, // Compile fully upfront
-		})
-		if err != nil {
-			if jsErr, ok := err.(*v8.JSError); ok {
-				return "", fmt.Errorf("compile error: %s\n%s", jsErr.Message, jsErr.StackTrace)
-			}
-			return "", fmt.Errorf("compile error: %w", err)
-		}
-		v.cachedScripts[hash] = script
+	script, err := v.script(bundle)
+	if err != nil {
+		return "", err
 	}
 
 	// 3. Run cached script
@@ -110,6 +97,56 @@ func (v *V8Runtime) ExecuteWithProps(bundle, propsJSON string) (string, error) {
 	return val.String(), nil
 }
 
+// Compile compiles a bundle ahead of its first ExecuteWithProps
+func (v *V8Runtime) Compile(bundle string) error {
+	_, err := v.script(bundle)
+	return err
+}
+
+// script returns the compiled bundle, compiling it on first use
+func (v *V8Runtime) script(bundle string) (*v8.UnboundScript, error) {
+	hash := hashBundle(bundle)
+	if script, ok := v.cachedScripts[hash]; ok {
+		return script, nil
+	}
+	script, err := v.compile(bundle, hash)
+	if err != nil {
+		if jsErr, ok := err.(*v8.JSError); ok {
+			return nil, fmt.Errorf("compile error: %s\n%s", jsErr.Message, jsErr.StackTrace)
+		}
+		return nil, fmt.Errorf("compile error: %w", err)
+	}
+	v.cachedScripts[hash] = script
+	return script, nil
+}
+
+// compile compiles a bundle, from the code cache when another isolate or a previous run compiled it
+func (v *V8Runtime) compile(bundle, hash string) (*v8.UnboundScript, error) {
+	key := "v8-" + v8.Version() + "-" + hash
+	if v.codeCache != nil {
+		if data, ok, _ := v.codeCache.GetCodeCache(key); ok && len(data) > 0 {
+			cachedData := &v8.CompilerCachedData{Bytes: data}
+			script, err := v.isolate.CompileUnboundScript(bundle, "bundle.js", v8.CompileOptions{CachedData: cachedData})
+			if err != nil || !cachedData.Rejected {
+				return script, err
+			}
+			// Code compiled with other V8 flags is rejected, it's replaced below
+		}
+	}
+
+	script, err := v.isolate.CompileUnboundScript(bundle, "bundle.js", v8.CompileOptions{
+		Mode: v8.CompileModeEager, // Compile fully upfront
+	})
+	if err != nil {
+		return nil, err
+	}
+	if v.codeCache != nil {
+		// Errors only cost the other isolates a compilation
+		_ = v.codeCache.SetCodeCache(key, script.CreateCodeCache().Bytes)
+	}
+	return script, nil
+}
+
 // Reset prepares the runtime for reuse
 // Context is reused to avoid expensive context creation/destruction
 // Context is only recreated periodically to prevent memory buildup.
@@ -131,6 +168,12 @@ func (v *V8Runtime) Reset() {
 	// - Footer: calculates __ssr_result (new result each execution)
 }
 
+// HeapStats returns the heap usage of the isolate
+func (v *V8Runtime) HeapStats() HeapStats {
+	stats := v.isolate.GetHeapStatistics()
+	return HeapStats{Used: stats.UsedHeapSize, Limit: stats.HeapSizeLimit}
+}
+
 // Destroy permanently destroys the runtime
 func (v *V8Runtime) Destroy() {
 	if v.context != nil {
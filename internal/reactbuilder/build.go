@@ -1,7 +1,9 @@
 package reactbuilder
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -29,13 +31,51 @@ var consolePolyfill = `globalThis.__ssr_errors=[];var console = {log: function()
 var urlPolyfill = `if(typeof URL==="undefined"){function URL(u,b){if(b&&u.indexOf("://")===-1){u=b.replace(/\/$/,"")+"/"+u.replace(/^\//,"")}var m=u.match(/^(([^:/?#]+):)?(\/\/([^/?#]*))?([^?#]*)(\?([^#]*))?(#(.*))?/);this.href=u;this.protocol=(m[2]||"")+ ":";this.host=m[4]||"";this.hostname=this.host.split(":")[0];this.port=this.host.split(":")[1]||"";this.pathname=m[5]||"/";this.search=m[6]||"";this.hash=m[8]||"";this.origin=this.protocol+"//"+this.host}URL.prototype.toString=function(){return this.href}}`
 var messageChannelPolyfill = `if(typeof MessageChannel==="undefined"){function MessageChannel(){var self=this;this.port1={postMessage:function(msg){if(self.port2.onmessage)setTimeout(function(){self.port2.onmessage({data:msg})},0)}};this.port2={postMessage:function(msg){if(self.port1.onmessage)setTimeout(function(){self.port1.onmessage({data:msg})},0)}}}}`
 
+// es5Polyfill adds the ES2015+ built-ins used by React to engines that only support ES5, e.g. Duktape
+var es5Polyfill = `(function(g){function C(){this._k=[];this._v=[];this.size=0}C.prototype._i=function(k){for(var i=0;i<this._k.length;i++){var x=this._k[i];if(x===k||x!==x&&k!==k)return i}return -1};C.prototype.get=function(k){var i=this._i(k);return i<0?undefined:this._v[i]};C.prototype.set=function(k,v){var i=this._i(k);if(i<0){this._k.push(k);this._v.push(v);this.size++}else{this._v[i]=v}return this};C.prototype.has=function(k){return this._i(k)>=0};C.prototype["delete"]=function(k){var i=this._i(k);if(i<0)return false;this._k.splice(i,1);this._v.splice(i,1);this.size--;return true};C.prototype.clear=function(){this._k=[];this._v=[];this.size=0};C.prototype.forEach=function(f,t){for(var i=0;i<this._k.length;i++)f.call(t,this._v[i],this._k[i],this)};C.prototype.add=function(v){return this.set(v,v)};function collection(add){return function(e){C.call(this);if(e)for(var i=0;i<e.length;i++)add.call(this,e[i])}}if(typeof g.Map==="undefined"){g.Map=collection(function(e){this.set(e[0],e[1])});g.Map.prototype=Object.create(C.prototype)}if(typeof g.Set==="undefined"){g.Set=collection(C.prototype.add);g.Set.prototype=Object.create(C.prototype)}if(typeof g.WeakMap==="undefined"){g.WeakMap=collection(function(e){this.set(e[0],e[1])});g.WeakMap.prototype=Object.create(C.prototype)}if(typeof g.WeakSet==="undefined"){g.WeakSet=collection(C.prototype.add);g.WeakSet.prototype=Object.create(C.prototype)}if(!Array.from)Array.from=function(a,f){var r=[];if(a.forEach&&typeof a.length!=="number"){a.forEach(function(v){r.push(v)})}else{for(var i=0;i<a.length;i++)r.push(a[i])}return f?r.map(f):r};if(!Array.prototype.includes)Array.prototype.includes=function(v){return this.indexOf(v)>=0||v!==v&&this.some(function(x){return x!==x})};if(!Array.prototype.fill)Array.prototype.fill=function(v){for(var i=0;i<this.length;i++)this[i]=v;return this};if(!Object.entries)Object.entries=function(o){return Object.keys(o).map(function(k){return[k,o[k]]})};if(!Object.values)Object.values=function(o){return Object.keys(o).map(function(k){return o[k]})}})(globalThis);`
+
+// esbuildTargets maps the ES targets reported by the JS runtimes to esbuild targets
+// esbuild lowers arrow functions, template literals and the like to ES5 but not let, const, classes, destructuring or
+// generators, so code rendered by ES5 runtimes must already be written in or compiled to ES5 (e.g. by Babel or tsc).
+var esbuildTargets = map[string]esbuildApi.Target{
+	"":       esbuildApi.ESNext,
+	"esnext": esbuildApi.ESNext,
+	"es5":    esbuildApi.ES5,
+	"es2015": esbuildApi.ES2015,
+	"es2016": esbuildApi.ES2016,
+	"es2017": esbuildApi.ES2017,
+	"es2018": esbuildApi.ES2018,
+	"es2019": esbuildApi.ES2019,
+	"es2020": esbuildApi.ES2020,
+	"es2021": esbuildApi.ES2021,
+	"es2022": esbuildApi.ES2022,
+	"es2023": esbuildApi.ES2023,
+	"es2024": esbuildApi.ES2024,
+}
+
+// serverBanner returns the polyfills prepended to server bundles lowered to esTarget
+func serverBanner(esTarget string) string {
+	banner := globalThisPolyfill
+	if esTarget == "es5" {
+		banner += es5Polyfill
+	}
+	return banner + urlPolyfill + textEncoderPolyfill + messageChannelPolyfill + processPolyfill + consolePolyfill
+}
+
 type BuildResult struct {
 	JS           string
 	CSS          string
+	SourceMap    string // Source map for JS, only generated for server builds
 	Dependencies []string
 }
 
-func BuildServer(buildContents, frontendDir, assetRoute string) (BuildResult, error) {
+// BuildServer builds the bundle rendered by the JS runtimes
+// esTarget is the newest ECMAScript version the runtime supports, e.g. "es5", empty for the latest.
+func BuildServer(buildContents, frontendDir, assetRoute, esTarget string) (BuildResult, error) {
+	target, ok := esbuildTargets[esTarget]
+	if !ok {
+		return BuildResult{}, fmt.Errorf("unsupported ES target %q", esTarget)
+	}
 	opts := esbuildApi.BuildOptions{
 		Stdin: &esbuildApi.StdinOptions{
 			Contents:   buildContents,
@@ -43,6 +83,7 @@ func BuildServer(buildContents, frontendDir, assetRoute string) (BuildResult, er
 			ResolveDir: frontendDir,
 		},
 		Platform:          esbuildApi.PlatformNode,
+		Target:            target,
 		Bundle:            true,
 		Write:             false,
 		Outdir:            "/",
@@ -52,11 +93,13 @@ func BuildServer(buildContents, frontendDir, assetRoute string) (BuildResult, er
 		MinifyIdentifiers: true,
 		MinifySyntax:      true,
 		Loader:            loaders,
+		// Source maps are kept in memory to remap stack traces of render errors
+		Sourcemap: esbuildApi.SourceMapExternal,
 		// Remove legal comments so they don't interfere with eval result
 		LegalComments: esbuildApi.LegalCommentsNone,
 		// We can inject the polyfills at the top of the generated js
 		Banner: map[string]string{
-			"js": globalThisPolyfill + urlPolyfill + textEncoderPolyfill + messageChannelPolyfill + processPolyfill + consolePolyfill,
+			"js": serverBanner(esTarget),
 		},
 		// Footer returns globalThis.__ssr_result - never affected by minification
 		// Also prepends any console.error messages as HTML comment for debugging
@@ -64,10 +107,32 @@ func BuildServer(buildContents, frontendDir, assetRoute string) (BuildResult, er
 			"js": "globalThis.__ssr_result+(globalThis.__ssr_errors&&globalThis.__ssr_errors.length?'<!-- SSR_ERRORS: '+globalThis.__ssr_errors.join(' | ')+' -->':'')",
 		},
 	}
-	return build(opts, false)
+	result, err := build(opts, false)
+	var buildErr *BuildError
+	if esTarget == "es5" && errors.As(err, &buildErr) && strings.Contains(buildErr.Text, "to the configured target environment") {
+		buildErr.Text += ": the frontend code must be compiled to ES5 before esbuild bundles it for ES5 runtimes"
+	}
+	return result, err
 }
 
-func BuildClient(buildContents, frontendDir, assetRoute string, minify bool) (BuildResult, error) {
+// BuildClient builds the hydration bundle for the browser.
+// Packages in externals are left as bare ESM imports so they can be resolved through an import map.
+// reactRefresh installs React Refresh and registers components so the page can be hot updated.
+func BuildClient(buildContents, frontendDir, assetRoute string, minify bool, externals []string, reactRefresh bool) (BuildResult, error) {
+	if reactRefresh {
+		setupImport := reactRefreshSetupImport
+		if len(externals) > 0 {
+			// External imports are hoisted above the bundled code, so the setup is its own module imported first
+			setup, err := buildReactRefreshSetup(frontendDir)
+			if err != nil {
+				return BuildResult{}, err
+			}
+			setupImport = `import "data:text/javascript;base64,` + base64.StdEncoding.EncodeToString([]byte(setup)) + `";`
+			externals = append(externals[:len(externals):len(externals)], "data:*")
+		}
+		// Imports are evaluated in order, so the setup runs before react-dom is loaded
+		buildContents = setupImport + "\n" + buildContents + "\n" + reactRefreshModulesImport
+	}
 	opts := esbuildApi.BuildOptions{
 		Stdin: &esbuildApi.StdinOptions{
 			Contents:   buildContents,
@@ -84,25 +149,59 @@ func BuildClient(buildContents, frontendDir, assetRoute string, minify bool) (Bu
 		MinifySyntax:      minify,
 		Loader:            loaders,
 	}
+	if len(externals) > 0 {
+		// Bare imports only work in module scripts, so externals require ESM output
+		opts.External = externals
+		opts.Format = esbuildApi.FormatESModule
+	}
+	if reactRefresh {
+		opts.Plugins = []esbuildApi.Plugin{reactRefreshPlugin()}
+	}
 	return build(opts, true)
 }
 
+// BuildError is the first esbuild diagnostic of a failed build
+type BuildError struct {
+	Text     string
+	File     string // Absolute path of the file with the error, empty if unknown
+	Line     int    // 1-based, 0 if unknown
+	Column   int    // 1-based, 0 if unknown
+	LineText string
+}
+
+func (e *BuildError) Error() string {
+	if e.File == "" {
+		return e.Text
+	}
+	return fmt.Sprintf("%s\n    at %s:%d:%d", e.Text, e.File, e.Line, e.Column)
+}
+
+// newBuildError converts an esbuild message to a BuildError
+func newBuildError(message esbuildApi.Message) *BuildError {
+	buildErr := &BuildError{Text: message.Text}
+	if loc := message.Location; loc != nil {
+		if loc.File != "" && loc.File != "<stdin>" {
+			buildErr.File = utils.GetFullFilePath(loc.File)
+		}
+		buildErr.Line = loc.Line
+		buildErr.Column = loc.Column + 1
+		buildErr.LineText = loc.LineText
+	}
+	return buildErr
+}
+
 func build(buildOptions esbuildApi.BuildOptions, isClient bool) (BuildResult, error) {
 	result := esbuildApi.Build(buildOptions)
 	if len(result.Errors) > 0 {
-		fileLocation := "unknown"
-		lineNum := "unknown"
-		if result.Errors[0].Location != nil {
-			fileLocation = result.Errors[0].Location.File
-			lineNum = result.Errors[0].Location.LineText
-		}
-		return BuildResult{}, fmt.Errorf("%s <br>in %s <br>at %s", result.Errors[0].Text, fileLocation, lineNum)
+		return BuildResult{}, newBuildError(result.Errors[0])
 	}
 
 	var br BuildResult
 	for _, file := range result.OutputFiles {
 		if strings.HasSuffix(file.Path, "stdin.js") {
 			br.JS = string(file.Contents)
+		} else if strings.HasSuffix(file.Path, "stdin.js.map") {
+			br.SourceMap = string(file.Contents)
 		} else if strings.HasSuffix(file.Path, "stdin.css") {
 			br.CSS = string(file.Contents)
 		}
@@ -126,9 +225,9 @@ func getDependencyPathsFromMetafile(metafile string) []string {
 	}
 
 	var dependencyPaths []string
-	// Ignore dependencies in node_modules
+	// Ignore dependencies in node_modules and modules generated by plugins
 	for key := range meta.Inputs {
-		if !strings.Contains(key, "/node_modules/") {
+		if !strings.Contains(key, "/node_modules/") && !strings.HasPrefix(key, "gossr-") {
 			dependencyPaths = append(dependencyPaths, utils.GetFullFilePath(key))
 		}
 	}
@@ -0,0 +1,29 @@
+package reactbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildServer_ESTarget(t *testing.T) {
+	contents := `globalThis.__ssr_result = [1, 2].map((n) => n * 2).join(",")`
+
+	result, err := BuildServer(contents, t.TempDir(), "/assets", "")
+	assert.Nil(t, err, "BuildServer should not return an error")
+	assert.Contains(t, result.JS, "=>", "Bundles should keep modern syntax by default")
+	assert.NotContains(t, result.JS, es5Polyfill, "The ES5 polyfill should only be added for ES5 runtimes")
+
+	result, err = BuildServer(contents, t.TempDir(), "/assets", "es5")
+	assert.Nil(t, err, "BuildServer should not return an error")
+	assert.NotContains(t, result.JS, "=>", "Arrow functions should be lowered to ES5")
+	assert.Contains(t, result.JS, es5Polyfill, "The ES5 polyfill should be added for ES5 runtimes")
+
+	_, err = BuildServer(`const n = 1; globalThis.__ssr_result = n`, t.TempDir(), "/assets", "es5")
+	if assert.Error(t, err, "let and const can't be lowered to ES5") {
+		assert.Contains(t, err.Error(), "the frontend code must be compiled to ES5", "The error should explain how to build for ES5 runtimes")
+	}
+
+	_, err = BuildServer(contents, t.TempDir(), "/assets", "es3")
+	assert.EqualError(t, err, `unsupported ES target "es3"`)
+}
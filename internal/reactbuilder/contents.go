@@ -15,8 +15,10 @@ import React from "react";
 import App from "{{ .FilePath }}";
 {{ if .SuppressConsoleLog }}console.log = () => {};{{ end }}
 {{ .RenderFunction }}`
-var serverRenderFunction = `renderToString(<App {...props} />);`
-var serverRenderFunctionWithLayout = `renderToString(<Layout><App {...props} /></Layout>);`
+
+// The server bundles return globalThis.__ssr_result, which is never minified
+var serverRenderFunction = `globalThis.__ssr_result = renderToString(<App {...props} />);`
+var serverRenderFunctionWithLayout = `globalThis.__ssr_result = renderToString(<Layout><App {...props} /></Layout>);`
 var clientRenderFunction = `hydrateRoot(document.getElementById("root"), <App {...props} />);`
 var clientRenderFunctionWithLayout = `hydrateRoot(document.getElementById("root"), <Layout><App {...props} /></Layout>);`
 
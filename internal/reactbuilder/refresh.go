@@ -0,0 +1,271 @@
+package reactbuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	esbuildApi "github.com/evanw/esbuild/pkg/api"
+	"github.com/yejune/gotossr/internal/utils"
+)
+
+// reactRefreshSetupImport must be the first import of client bundles using React Refresh.
+// It installs the refresh runtime before react-dom is loaded, which React Refresh requires.
+const reactRefreshSetupImport = `import "gossr:react-refresh";`
+
+// reactRefreshModulesImport exposes the page's React modules to hot update bundles.
+// It must be the last import so it doesn't load react-dom before the setup.
+const reactRefreshModulesImport = `import "gossr:react-refresh-modules";`
+
+// reactRefreshSetup installs React Refresh. Hooks aren't given React Refresh signatures, which need a Babel or SWC
+// transform, so files whose hook calls change are reloaded instead of keeping state that no longer matches the hooks.
+var reactRefreshSetup = `
+import RefreshRuntime from "react-refresh/runtime";
+RefreshRuntime.injectIntoGlobalHook(window);
+window.$RefreshReg$ = () => {};
+window.$RefreshSig$ = () => (type) => type;
+window.__gossr_hmr = {
+  modules: {},
+  hooks: {},
+  hooksChanged: false,
+  register(type, id) {
+    if (RefreshRuntime.isLikelyComponentType(type)) {
+      RefreshRuntime.register(type, id);
+    }
+  },
+  registerHooks(file, hooks) {
+    if (file in this.hooks && this.hooks[file] !== hooks) {
+      this.hooksChanged = true;
+    }
+    this.hooks[file] = hooks;
+  },
+  update(file, exports) {
+    const values = Object.values(exports);
+    // Only modules exporting nothing but components are refresh boundaries
+    if (values.length === 0 || !values.every((value) => RefreshRuntime.isLikelyComponentType(value))) {
+      console.log("[gossr] " + file + " can't be hot updated, reloading...");
+      window.location.reload();
+      return;
+    }
+    if (this.hooksChanged) {
+      console.log("[gossr] Hooks changed in " + file + ", reloading...");
+      window.location.reload();
+      return;
+    }
+    RefreshRuntime.performReactRefresh();
+    console.log("[gossr] Hot updated " + file);
+  },
+};
+`
+
+var reactRefreshModules = `
+import React from "react";
+import ReactDOM from "react-dom";
+import ReactDOMClient from "react-dom/client";
+import JSXRuntime from "react/jsx-runtime";
+window.__gossr_hmr.modules = {
+  "react": React,
+  "react-dom": ReactDOM,
+  "react-dom/client": ReactDOMClient,
+  "react/jsx-runtime": JSXRuntime,
+};
+`
+
+// sharedModules are taken from the page in hot update bundles, so updates use the same React instance
+var sharedModulesFilter = `^(react|react-dom|react-dom/client|react/jsx-runtime)$`
+
+// hookCallRegex matches hook calls, e.g. useState( or useCustomHook (
+var hookCallRegex = regexp.MustCompile(`\buse[A-Z][\w$]*\s*\(`)
+
+// componentDeclarationRegex matches top level declarations that look like components (PascalCase)
+var componentDeclarationRegex = regexp.MustCompile(`(?m)^(?:export\s+(?:default\s+)?)?(?:async\s+)?(?:function\s*\*?\s*|class\s+|(?:const|let|var)\s+)([A-Z][\w$]*)`)
+
+// ReactRefreshAvailable reports whether the react-refresh package is installed for the frontend
+func ReactRefreshAvailable(frontendDir string) bool {
+	for dir := frontendDir; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "node_modules", "react-refresh", "package.json")); err == nil {
+			return true
+		}
+		if filepath.Dir(dir) == dir {
+			return false
+		}
+	}
+}
+
+// reactRefreshPlugin provides the refresh setup modules and registers components of app files with React Refresh
+func reactRefreshPlugin() esbuildApi.Plugin {
+	return esbuildApi.Plugin{
+		Name: "gossr-react-refresh",
+		Setup: func(build esbuildApi.PluginBuild) {
+			build.OnResolve(esbuildApi.OnResolveOptions{Filter: `^gossr:react-refresh`},
+				func(args esbuildApi.OnResolveArgs) (esbuildApi.OnResolveResult, error) {
+					return esbuildApi.OnResolveResult{Path: args.Path, Namespace: "gossr-refresh"}, nil
+				})
+			build.OnLoad(esbuildApi.OnLoadOptions{Filter: `.*`, Namespace: "gossr-refresh"},
+				func(args esbuildApi.OnLoadArgs) (esbuildApi.OnLoadResult, error) {
+					contents := reactRefreshSetup
+					if args.Path == "gossr:react-refresh-modules" {
+						contents = reactRefreshModules
+					}
+					// Resolve react and react-refresh from the importing app
+					return esbuildApi.OnLoadResult{Contents: &contents, ResolveDir: build.InitialOptions.Stdin.ResolveDir, Loader: esbuildApi.LoaderJS}, nil
+				})
+			build.OnLoad(esbuildApi.OnLoadOptions{Filter: `\.[jt]sx?$`, Namespace: "file"},
+				func(args esbuildApi.OnLoadArgs) (esbuildApi.OnLoadResult, error) {
+					if strings.Contains(args.Path, "/node_modules/") {
+						return esbuildApi.OnLoadResult{}, nil
+					}
+					source, err := os.ReadFile(args.Path)
+					if err != nil {
+						return esbuildApi.OnLoadResult{}, err
+					}
+					contents := string(source) + componentRegistrations(string(source), args.Path)
+					return esbuildApi.OnLoadResult{Contents: &contents, Loader: loaderForFile(args.Path)}, nil
+				})
+		},
+	}
+}
+
+// buildReactRefreshSetup bundles the React Refresh setup into a standalone module
+func buildReactRefreshSetup(frontendDir string) (string, error) {
+	result := esbuildApi.Build(esbuildApi.BuildOptions{
+		Stdin: &esbuildApi.StdinOptions{
+			Contents:   reactRefreshSetup,
+			Loader:     esbuildApi.LoaderJS,
+			ResolveDir: frontendDir,
+		},
+		Bundle: true,
+		Write:  false,
+		Format: esbuildApi.FormatESModule,
+	})
+	if len(result.Errors) > 0 {
+		return "", newBuildError(result.Errors[0])
+	}
+	return string(result.OutputFiles[0].Contents), nil
+}
+
+// sharedModulesPlugin replaces React imports in hot update bundles with the modules already loaded by the page
+func sharedModulesPlugin() esbuildApi.Plugin {
+	return esbuildApi.Plugin{
+		Name: "gossr-shared-modules",
+		Setup: func(build esbuildApi.PluginBuild) {
+			build.OnResolve(esbuildApi.OnResolveOptions{Filter: sharedModulesFilter},
+				func(args esbuildApi.OnResolveArgs) (esbuildApi.OnResolveResult, error) {
+					return esbuildApi.OnResolveResult{Path: args.Path, Namespace: "gossr-shared"}, nil
+				})
+			build.OnLoad(esbuildApi.OnLoadOptions{Filter: `.*`, Namespace: "gossr-shared"},
+				func(args esbuildApi.OnLoadArgs) (esbuildApi.OnLoadResult, error) {
+					name, _ := json.Marshal(args.Path)
+					contents := fmt.Sprintf("module.exports = window.__gossr_hmr.modules[%s];", name)
+					return esbuildApi.OnLoadResult{Contents: &contents, Loader: esbuildApi.LoaderJS}, nil
+				})
+		},
+	}
+}
+
+// componentRegistrations returns code registering the file's top level components with React Refresh
+func componentRegistrations(source, filePath string) string {
+	seen := make(map[string]bool)
+	var registrations strings.Builder
+	for _, match := range componentDeclarationRegex.FindAllStringSubmatch(source, -1) {
+		name := match[1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		id, _ := json.Marshal(filePath + " " + name)
+		fmt.Fprintf(&registrations, "window.__gossr_hmr.register(%s, %s);", name, id)
+	}
+	if registrations.Len() == 0 {
+		return ""
+	}
+	id, _ := json.Marshal(filePath)
+	hooks, _ := json.Marshal(hookCalls(source))
+	fmt.Fprintf(&registrations, "window.__gossr_hmr.registerHooks(%s, %s);", id, hooks)
+	return "\n;if (typeof window !== \"undefined\" && window.__gossr_hmr) {" + registrations.String() + "}\n"
+}
+
+// hookCalls returns the hook calls of a file in order, which change when hooks are added, removed or reordered
+func hookCalls(source string) string {
+	var hooks []string
+	for _, call := range hookCallRegex.FindAllString(source, -1) {
+		hooks = append(hooks, strings.TrimRight(call, " \t\r\n("))
+	}
+	return strings.Join(hooks, ",")
+}
+
+// loaderForFile returns the esbuild loader for a JS or TS file
+func loaderForFile(filePath string) esbuildApi.Loader {
+	switch filepath.Ext(filePath) {
+	case ".tsx":
+		return esbuildApi.LoaderTSX
+	case ".ts":
+		return esbuildApi.LoaderTS
+	case ".jsx":
+		return esbuildApi.LoaderJSX
+	default:
+		return esbuildApi.LoaderJS
+	}
+}
+
+// ErrHotUpdateUnsupported is returned by BuildHotUpdate when the update can't be applied without reloading the page
+var ErrHotUpdateUnsupported = errors.New("hot update would re-instantiate modules shared with the page")
+
+// BuildHotUpdate builds a hot update bundle for a changed file.
+// The bundle re-evaluates the file and hands the new exports to React Refresh. Only React is taken from the page,
+// so files importing other modules, e.g. context providers or stores, return ErrHotUpdateUnsupported instead of
+// creating second instances of them.
+func BuildHotUpdate(filePath, frontendDir, assetRoute string) (BuildResult, error) {
+	file, _ := json.Marshal(filePath)
+	result := esbuildApi.Build(esbuildApi.BuildOptions{
+		Stdin: &esbuildApi.StdinOptions{
+			Contents:   fmt.Sprintf("import * as __gossr_module from %s;\nwindow.__gossr_hmr.update(%s, __gossr_module);", file, file),
+			Loader:     esbuildApi.LoaderJS,
+			ResolveDir: frontendDir,
+		},
+		Bundle:     true,
+		Write:      false,
+		Outdir:     "/",
+		Format:     esbuildApi.FormatIIFE,
+		AssetNames: fmt.Sprintf("%s/[name]", strings.TrimPrefix(assetRoute, "/")),
+		Loader:     loaders,
+		Metafile:   true,
+		Plugins:    []esbuildApi.Plugin{sharedModulesPlugin(), reactRefreshPlugin()},
+	})
+	if len(result.Errors) > 0 {
+		return BuildResult{}, newBuildError(result.Errors[0])
+	}
+	if module := unsharedModule(result.Metafile, filePath); module != "" {
+		return BuildResult{}, fmt.Errorf("%w: %s", ErrHotUpdateUnsupported, module)
+	}
+	var br BuildResult
+	for _, outputFile := range result.OutputFiles {
+		if strings.HasSuffix(outputFile.Path, "stdin.js") {
+			br.JS = string(outputFile.Contents)
+		}
+	}
+	return br, nil
+}
+
+// unsharedModule returns a script module of the hot update, other than the changed file, that isn't taken from the page.
+// Assets and css don't hold state and are fine to load again.
+func unsharedModule(metafile, filePath string) string {
+	var meta metafileSchema
+	if err := json.Unmarshal([]byte(metafile), &meta); err != nil {
+		return ""
+	}
+	for input := range meta.Inputs {
+		if input == "<stdin>" || strings.HasPrefix(input, "gossr-shared:") || utils.GetFullFilePath(input) == utils.GetFullFilePath(filePath) {
+			continue
+		}
+		switch filepath.Ext(input) {
+		case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+			return input
+		}
+	}
+	return ""
+}
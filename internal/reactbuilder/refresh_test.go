@@ -0,0 +1,53 @@
+package reactbuilder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentRegistrations_Hooks(t *testing.T) {
+	source := "export default function Counter() {\n  const [count, setCount] = useState(0);\n  useEffect (() => {}, []);\n  return count;\n}"
+	registrations := componentRegistrations(source, "/src/Counter.tsx")
+	assert.Contains(t, registrations, `window.__gossr_hmr.register(Counter, "/src/Counter.tsx Counter");`)
+	assert.Contains(t, registrations, `window.__gossr_hmr.registerHooks("/src/Counter.tsx", "useState,useEffect");`, "Hook calls should be registered so changes reload the page")
+}
+
+func TestBuildClient_ReactRefreshExternals(t *testing.T) {
+	// A stand-in for react-refresh, the setup only needs its runtime to be resolvable
+	frontendDir := t.TempDir()
+	runtimeDir := filepath.Join(frontendDir, "node_modules", "react-refresh")
+	if err := os.MkdirAll(runtimeDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(runtimeDir, "package.json"), []byte(`{"name": "react-refresh"}`), 0o644)
+	os.WriteFile(filepath.Join(runtimeDir, "runtime.js"), []byte(`exports.injectIntoGlobalHook = function () {};`), 0o644)
+
+	result, err := BuildClient(`import { hydrateRoot } from "react-dom/client"; console.log(hydrateRoot);`,
+		frontendDir, "/assets", false, []string{"react", "react-dom", "react-dom/*", "react/*"}, true)
+	assert.Nil(t, err, "BuildClient should not return an error")
+	setup := strings.Index(result.JS, `import "data:text/javascript;base64,`)
+	reactDOM := strings.Index(result.JS, `from "react-dom/client"`)
+	assert.True(t, setup >= 0 && setup < reactDOM, "The React Refresh setup should be imported before react-dom")
+}
+
+func TestBuildHotUpdate_UnsharedModules(t *testing.T) {
+	frontendDir := t.TempDir()
+	os.WriteFile(filepath.Join(frontendDir, "countStore.ts"), []byte(`export const store = { count: 0 };`), 0o644)
+	os.WriteFile(filepath.Join(frontendDir, "Counter.css"), []byte(`.counter { color: red; }`), 0o644)
+	os.WriteFile(filepath.Join(frontendDir, "Counter.tsx"), []byte(`import { useState } from "react";
+import "./Counter.css";
+export default function Counter() { const [count] = useState(0); return <p className="counter">{count}</p>; }`), 0o644)
+	os.WriteFile(filepath.Join(frontendDir, "Store.tsx"), []byte(`import { store } from "./countStore";
+export default function Store() { return <p>{store.count}</p>; }`), 0o644)
+
+	result, err := BuildHotUpdate(filepath.Join(frontendDir, "Counter.tsx"), frontendDir, "/assets")
+	assert.Nil(t, err, "Files only importing React and css should be hot updatable")
+	assert.Contains(t, result.JS, `window.__gossr_hmr.modules["react"]`)
+
+	_, err = BuildHotUpdate(filepath.Join(frontendDir, "Store.tsx"), frontendDir, "/assets")
+	assert.ErrorIs(t, err, ErrHotUpdateUnsupported, "Hot updates would create a second store instance")
+}
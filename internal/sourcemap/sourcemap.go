@@ -0,0 +1,197 @@
+// Package sourcemap decodes source map v3 files produced by esbuild and maps
+// positions in generated bundles back to the original source files.
+package sourcemap
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Map is a decoded source map
+type Map struct {
+	sources  []string
+	contents []string
+	names    []string
+	lines    [][]mapping // Indexed by generated line (0-based)
+}
+
+// mapping is a single decoded segment of the "mappings" field
+type mapping struct {
+	generatedColumn int
+	source          int // -1 if the segment has no source
+	line            int
+	column          int
+	name            int // -1 if the segment has no name
+}
+
+// Position is a location in an original source file
+type Position struct {
+	Source string
+	Line   int // 1-based
+	Column int // 1-based
+	Name   string
+}
+
+type rawMap struct {
+	Version        int      `json:"version"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent"`
+	Names          []string `json:"names"`
+	Mappings       string   `json:"mappings"`
+}
+
+// Parse decodes a source map. Relative source paths are resolved against root,
+// which should be the directory of the generated file (esbuild's outdir).
+func Parse(data []byte, root string) (*Map, error) {
+	var raw rawMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Version != 3 {
+		return nil, errors.New("unsupported source map version " + strconv.Itoa(raw.Version))
+	}
+	m := &Map{
+		sources:  make([]string, len(raw.Sources)),
+		contents: raw.SourcesContent,
+		names:    raw.Names,
+	}
+	for i, source := range raw.Sources {
+		if root != "" && !path.IsAbs(source) && !strings.HasPrefix(source, "<") {
+			source = path.Join(root, source)
+		}
+		m.sources[i] = source
+	}
+	lines, err := decodeMappings(raw.Mappings)
+	if err != nil {
+		return nil, err
+	}
+	m.lines = lines
+	return m, nil
+}
+
+// decodeMappings decodes the base64 VLQ "mappings" field
+func decodeMappings(mappings string) ([][]mapping, error) {
+	var lines [][]mapping
+	var current []mapping
+	source, line, column, name := 0, 0, 0, 0
+	for _, group := range strings.Split(mappings, ";") {
+		current = nil
+		generatedColumn := 0
+		for _, segment := range strings.Split(group, ",") {
+			if segment == "" {
+				continue
+			}
+			fields, err := decodeVLQ(segment)
+			if err != nil {
+				return nil, err
+			}
+			generatedColumn += fields[0]
+			m := mapping{generatedColumn: generatedColumn, source: -1, name: -1}
+			if len(fields) >= 4 {
+				source += fields[1]
+				line += fields[2]
+				column += fields[3]
+				m.source, m.line, m.column = source, line, column
+			}
+			if len(fields) >= 5 {
+				name += fields[4]
+				m.name = name
+			}
+			current = append(current, m)
+		}
+		sort.SliceStable(current, func(i, j int) bool {
+			return current[i].generatedColumn < current[j].generatedColumn
+		})
+		lines = append(lines, current)
+	}
+	return lines, nil
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes all base64 VLQ values in a segment
+func decodeVLQ(segment string) ([]int, error) {
+	var values []int
+	value, shift := 0, 0
+	for i := 0; i < len(segment); i++ {
+		digit := strings.IndexByte(base64Chars, segment[i])
+		if digit < 0 {
+			return nil, errors.New("invalid character in source map mappings")
+		}
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+		if value&1 != 0 {
+			values = append(values, -(value >> 1))
+		} else {
+			values = append(values, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, errors.New("truncated source map segment")
+	}
+	return values, nil
+}
+
+// Lookup returns the original position of a generated position.
+// line and column are 1-based, as reported in JS stack traces.
+func (m *Map) Lookup(line, column int) (Position, bool) {
+	if line < 1 || line > len(m.lines) {
+		return Position{}, false
+	}
+	segments := m.lines[line-1]
+	// Find the last segment starting at or before the column
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].generatedColumn > column-1
+	}) - 1
+	if i < 0 || segments[i].source < 0 || segments[i].source >= len(m.sources) {
+		return Position{}, false
+	}
+	seg := segments[i]
+	pos := Position{
+		Source: m.sources[seg.source],
+		Line:   seg.line + 1,
+		Column: seg.column + 1,
+	}
+	if seg.name >= 0 && seg.name < len(m.names) {
+		pos.Name = m.names[seg.name]
+	}
+	return pos, true
+}
+
+// SourceContent returns the embedded contents of an original source file
+func (m *Map) SourceContent(source string) (string, bool) {
+	for i, s := range m.sources {
+		if s == source && i < len(m.contents) {
+			return m.contents[i], true
+		}
+	}
+	return "", false
+}
+
+// generatedFileRegex matches positions in the script names used by the JS runtimes
+var generatedFileRegex = regexp.MustCompile(`(?:render\.js|bundle\.js|<input>|<eval>):(\d+):(\d+)`)
+
+// RemapStack rewrites generated positions in a stack trace to original source positions.
+// lineOffset is the number of lines that were prepended to the bundle before execution.
+// Positions that can't be mapped are left unchanged.
+func (m *Map) RemapStack(stack string, lineOffset int) string {
+	return generatedFileRegex.ReplaceAllStringFunc(stack, func(match string) string {
+		parts := generatedFileRegex.FindStringSubmatch(match)
+		line, _ := strconv.Atoi(parts[1])
+		column, _ := strconv.Atoi(parts[2])
+		pos, ok := m.Lookup(line-lineOffset, column)
+		if !ok {
+			return match
+		}
+		return pos.Source + ":" + strconv.Itoa(pos.Line) + ":" + strconv.Itoa(pos.Column)
+	})
+}
@@ -0,0 +1,49 @@
+package sourcemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Generated by esbuild for a bundle of Home.tsx with a one line banner:
+//
+//	var x=1;
+//	function o(){throw new Error("boom")}o();
+const testMap = `{
+  "version": 3,
+  "sources": ["tmp/smt/src/Home.tsx", "<stdin>"],
+  "sourcesContent": ["export default function Home(){ throw new Error(\"boom\"); }\n", "import H from \"./Home.tsx\"; H();"],
+  "mappings": ";AAAe,SAARA,GAAuB,CAAE,MAAM,IAAI,MAAM,MAAM,CAAG,CCA7BC,EAAE",
+  "names": ["Home", "Home"]
+}`
+
+func TestMap_Lookup(t *testing.T) {
+	m, err := Parse([]byte(testMap), "/")
+	assert.Nil(t, err, "Parse should not return an error, got %v", err)
+
+	// "throw" in the generated bundle
+	pos, ok := m.Lookup(2, 14)
+	assert.True(t, ok)
+	assert.Equal(t, "/tmp/smt/src/Home.tsx", pos.Source)
+	assert.Equal(t, 1, pos.Line)
+	assert.Equal(t, 33, pos.Column)
+
+	// The banner has no mappings
+	_, ok = m.Lookup(1, 1)
+	assert.False(t, ok)
+
+	content, ok := m.SourceContent("/tmp/smt/src/Home.tsx")
+	assert.True(t, ok)
+	assert.Contains(t, content, "export default function Home()")
+}
+
+func TestMap_RemapStack(t *testing.T) {
+	m, err := Parse([]byte(testMap), "/")
+	assert.Nil(t, err, "Parse should not return an error, got %v", err)
+
+	stack := "Error: boom\n    at o (render.js:3:14)\n    at native (other.js:1:1)"
+	remapped := m.RemapStack(stack, 1)
+	assert.Contains(t, remapped, "at o (/tmp/smt/src/Home.tsx:1:33)")
+	assert.Contains(t, remapped, "other.js:1:1", "Unknown scripts should be left unchanged")
+}
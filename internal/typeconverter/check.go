@@ -0,0 +1,63 @@
+package typeconverter
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yejune/gotossr/internal/utils"
+)
+
+// Check generates the outputs in memory and compares them with the files at the configured paths
+// It returns a unified diff of the files that are out of date, or an empty string if they are all up to date.
+// Pages are declared at runtime, so unless config.Routes is set the Routes of the existing types file are kept.
+func Check(config Config) (string, error) {
+	if config.Routes == nil && config.GeneratedTypesPath != "" {
+		routes, err := loadRoutes(utils.GetFullFilePath(config.GeneratedTypesPath))
+		if err != nil {
+			return "", err
+		}
+		config.Routes = routes
+	}
+	result, err := Generate(config)
+	if err != nil {
+		return "", err
+	}
+	outputs, err := result.outputs(config)
+	if err != nil {
+		return "", err
+	}
+	var diffs strings.Builder
+	for _, output := range outputs {
+		current, err := os.ReadFile(utils.GetFullFilePath(output.path))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		diffs.WriteString(unifiedDiff(output.path+" (current)", output.path+" (generated)", string(current), output.contents))
+	}
+	return diffs.String(), nil
+}
+
+// loadRoutes reads the page files and props types of the Routes interface of a generated types file
+func loadRoutes(fileName string) (map[string]string, error) {
+	routes := make(map[string]string)
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return routes, nil
+		}
+		return nil, err
+	}
+	_, block, found := strings.Cut(string(data), "export interface Routes {\n")
+	if !found {
+		return routes, nil
+	}
+	block, _, _ = strings.Cut(block, "\n}")
+	for _, line := range strings.Split(block, "\n") {
+		file, tsType, ok := strings.Cut(strings.TrimSpace(line), ": ")
+		if file, err := strconv.Unquote(file); ok && err == nil {
+			routes[file] = strings.TrimSuffix(tsType, ";")
+		}
+	}
+	return routes, nil
+}
@@ -0,0 +1,36 @@
+package typeconverter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	config := Config{
+		StructsFilePath:    "testdata/props.go",
+		GeneratedTypesPath: filepath.Join(t.TempDir(), "generated.d.ts"),
+		Routes:             map[string]string{"Home.tsx": "PageProps"},
+	}
+
+	diff, err := Check(config)
+	assert.Nil(t, err, "Check should not return an error")
+	assert.Contains(t, diff, "+++ "+config.GeneratedTypesPath+" (generated)", "A missing types file should be out of date")
+
+	_, err = Start(config)
+	assert.Nil(t, err, "Start should not return an error")
+	config.Routes = nil
+	diff, err = Check(config)
+	assert.Nil(t, err, "Check should not return an error")
+	assert.Equal(t, "", diff, "The routes of the existing types file should be kept")
+
+	types, _ := os.ReadFile(config.GeneratedTypesPath)
+	stale := strings.Replace(string(types), "    id: number;\n}", "    id: string;\n}", 1)
+	os.WriteFile(config.GeneratedTypesPath, []byte(stale), 0644)
+	diff, err = Check(config)
+	assert.Nil(t, err, "Check should not return an error")
+	assert.Contains(t, diff, "@@ -4,7 +4,7 @@\n export type Role = \"admin\" | \"member\";\n export type Level = 0 | 1;\n export interface Base {\n-    id: string;\n+    id: number;\n }\n", "The diff should show the changed line")
+}
@@ -0,0 +1,124 @@
+package typeconverter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes
+const diffContext = 3
+
+// unifiedDiff returns a unified diff of two texts, empty if they have the same lines
+// Line endings and a trailing newline are ignored, e.g. when git checks out files with CRLF
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+	if slices.Equal(oldLines, newLines) {
+		return ""
+	}
+
+	// Longest common subsequence lengths of the suffixes
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Edit script, one operation per line: ' ' unchanged, '-' removed, '+' added
+	type edit struct {
+		op   byte
+		line string
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			edits = append(edits, edit{' ', oldLines[i]})
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', oldLines[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', newLines[j]})
+			j++
+		}
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "--- %s\n+++ %s\n", oldName, newName)
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			oldLine++
+			newLine++
+			start++
+			continue
+		}
+		// A hunk spans changes separated by less than twice the context
+		hunkStart := max(start-diffContext, 0)
+		end := start
+		for unchanged := 0; end < len(edits) && unchanged <= 2*diffContext; end++ {
+			if edits[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		hunkEnd := end
+		for hunkEnd > start && edits[hunkEnd-1].op == ' ' {
+			hunkEnd--
+		}
+		hunkEnd = min(hunkEnd+diffContext, len(edits))
+
+		hunkOldStart := oldLine - (start - hunkStart)
+		hunkNewStart := newLine - (start - hunkStart)
+		var oldCount, newCount int
+		var lines strings.Builder
+		for _, e := range edits[hunkStart:hunkEnd] {
+			if e.op != '+' {
+				oldCount++
+			}
+			if e.op != '-' {
+				newCount++
+			}
+			lines.WriteString(string(e.op) + e.line + "\n")
+		}
+		// An empty range starts at the line before it
+		if oldCount == 0 {
+			hunkOldStart--
+		}
+		if newCount == 0 {
+			hunkNewStart--
+		}
+		fmt.Fprintf(&result, "@@ -%d,%d +%d,%d @@\n%s", hunkOldStart, oldCount, hunkNewStart, newCount, lines.String())
+		for _, e := range edits[start:hunkEnd] {
+			if e.op != '+' {
+				oldLine++
+			}
+			if e.op != '-' {
+				newLine++
+			}
+		}
+		start = hunkEnd
+	}
+	return result.String()
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+}
@@ -0,0 +1,496 @@
+package typeconverter
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"go/constant"
+	"go/types"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	generatedHeader = "/* Do not change, this code is generated from Golang structs */\n\n"
+	indent          = "    "
+)
+
+// DefaultTypeMappings are the TypeScript types of well-known Go types, keyed by package path and type name
+// Types implementing encoding.TextMarshaler are mapped to string and json.Marshaler to any unless they are listed here
+var DefaultTypeMappings = map[string]string{
+	"time.Time":                "string",
+	"time.Duration":            "number",
+	"encoding/json.RawMessage": "any",
+	"encoding/json.Number":     "number",
+}
+
+// generator converts Go types to TypeScript declarations
+// Structs become interfaces and named types with constants become union types.
+// Declarations used by a type are emitted first, so that each declaration follows the ones it depends on
+type generator struct {
+	declared     map[*types.TypeName]bool
+	names        map[string]*types.TypeName // Declared types by TypeScript name, which doesn't include the package
+	customCode   map[string]string
+	typeMappings map[string]string
+}
+
+func newGenerator(customCode, typeMappings map[string]string) *generator {
+	mappings := make(map[string]string, len(DefaultTypeMappings)+len(typeMappings))
+	for goType, tsType := range DefaultTypeMappings {
+		mappings[goType] = tsType
+	}
+	for goType, tsType := range typeMappings {
+		mappings[goType] = tsType
+	}
+	return &generator{
+		declared:     make(map[*types.TypeName]bool),
+		names:        make(map[string]*types.TypeName),
+		customCode:   customCode,
+		typeMappings: mappings,
+	}
+}
+
+// declare returns the declaration of a named type, preceded by the declarations of the types it uses
+// ok is false if the type has no declaration of its own and is written inline instead.
+// The declaration is empty if it has already been returned before
+func (g *generator) declare(named *types.Named) (code string, ok bool, err error) {
+	named = named.Origin()
+	obj := named.Obj()
+	if declared, found := g.declared[obj]; found {
+		return "", declared, nil
+	}
+	structType, isStruct := named.Underlying().(*types.Struct)
+	if !isStruct {
+		values := enumValues(named)
+		g.declared[obj] = len(values) > 0
+		if len(values) == 0 {
+			return "", false, nil
+		}
+		if err := g.claimName(obj); err != nil {
+			return "", false, err
+		}
+		literals := make([]string, len(values))
+		for i, value := range values {
+			literal, _ := json.Marshal(value)
+			literals[i] = string(literal)
+		}
+		return fmt.Sprintf("export type %s = %s;", obj.Name(), strings.Join(literals, " | ")), true, nil
+	}
+	g.declared[obj] = true
+	if err := g.claimName(obj); err != nil {
+		return "", false, err
+	}
+
+	name := obj.Name()
+	if typeParams := named.TypeParams(); typeParams.Len() > 0 {
+		params := make([]string, typeParams.Len())
+		for i := range params {
+			params[i] = typeParams.At(i).Obj().Name()
+		}
+		name += "<" + strings.Join(params, ", ") + ">"
+	}
+	result := fmt.Sprintf("export interface %s {\n", name)
+	// Declarations of the types used by fields are prepended to the result
+	prepend := func(chunk string) {
+		if chunk != "" {
+			result = chunk + "\n" + result
+		}
+	}
+	var fields []string
+	for _, field := range structFields(structType) {
+		property, ok, err := g.property(field, prepend)
+		if err != nil {
+			return "", false, fmt.Errorf("cannot find type for %s.%s: %w", obj.Name(), field.v.Name(), err)
+		}
+		if !ok {
+			continue
+		}
+		if doc := reflect.StructTag(field.tag).Get("ts_doc"); doc != "" {
+			fields = append(fields, indent+"/** "+doc+" */")
+		}
+		fields = append(fields, indent+property+";")
+	}
+	result += strings.Join(fields, "\n") + "\n"
+	if code := g.customCode[obj.Name()]; code != "" {
+		result += indent + "//[" + obj.Name() + ":]\n" + code + "\n\n" + indent + "//[end]\n"
+	}
+	return result + "}", true, nil
+}
+
+// claimName reserves the TypeScript name of a declared type
+// Types of different packages with the same name would overwrite each other, so they are an error
+func (g *generator) claimName(obj *types.TypeName) error {
+	if other, ok := g.names[obj.Name()]; ok && other != obj {
+		return fmt.Errorf("%s and %s are both declared as %s, rename one of them", qualifiedName(other), qualifiedName(obj), obj.Name())
+	}
+	g.names[obj.Name()] = obj
+	return nil
+}
+
+// qualifiedName returns the package path and name of a type, the form used by the type mappings
+func qualifiedName(obj *types.TypeName) string {
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// routes returns the Routes interface mapping page files to the types of their props
+// Pages whose props aren't a declared struct get any
+func (g *generator) routes(routes map[string]string) string {
+	declared := make(map[string]bool)
+	for obj, ok := range g.declared {
+		declared[obj.Name()] = ok
+	}
+	var fields []string
+	for _, file := range slices.Sorted(maps.Keys(routes)) {
+		tsType := routes[file]
+		if !declared[tsType] {
+			tsType = "any"
+		}
+		fields = append(fields, fmt.Sprintf("%s%s: %s;", indent, strconv.Quote(file), tsType))
+	}
+	return "export interface Routes {\n" + strings.Join(fields, "\n") + "\n}"
+}
+
+// property returns the TypeScript property of a struct field, or false if the field isn't marshaled
+func (g *generator) property(field structField, prepend func(string)) (string, bool, error) {
+	prop, ok := parseProperty(field)
+	if !ok {
+		return "", false, nil
+	}
+	tsType := prop.tsType
+	if tsType == "" {
+		var err error
+		if tsType, err = g.tsType(field.v.Type(), prepend); err != nil {
+			return "", false, err
+		}
+		if prop.asString {
+			tsType = "string"
+		}
+	}
+	name := prop.name
+	if !isIdentifier(name) {
+		name = strconv.Quote(name)
+	}
+	if prop.optional {
+		name += "?"
+	}
+	if prop.nullable && tsType != "any" {
+		tsType += " | null"
+	}
+	return name + ": " + tsType, true, nil
+}
+
+// tsType returns the TypeScript type of t, passing the declarations of the named types it uses to prepend
+func (g *generator) tsType(t types.Type, prepend func(string)) (string, error) {
+	switch t := types.Unalias(t).(type) {
+	case *types.Pointer:
+		return g.tsType(t.Elem(), prepend)
+	case *types.Named:
+		return g.namedType(t, prepend)
+	case *types.Basic:
+		return basicType(t)
+	case *types.Slice:
+		// []byte is encoded as a base64 string
+		if elem, ok := t.Elem().Underlying().(*types.Basic); ok && elem.Kind() == types.Byte {
+			return "string", nil
+		}
+		return g.arrayType(t.Elem(), prepend)
+	case *types.Array:
+		return g.arrayType(t.Elem(), prepend)
+	case *types.Map:
+		key, err := g.tsType(t.Key(), prepend)
+		if err != nil {
+			return "", err
+		}
+		value, err := g.tsType(t.Elem(), prepend)
+		if err != nil {
+			return "", err
+		}
+		// Index signatures only accept string and number, union keys such as enums need a mapped type
+		if key != "string" && key != "number" {
+			return fmt.Sprintf("Partial<Record<%s, %s>>", key, value), nil
+		}
+		return fmt.Sprintf("{[key: %s]: %s}", key, value), nil
+	case *types.Struct:
+		var fields []string
+		for _, field := range structFields(t) {
+			property, ok, err := g.property(field, prepend)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				fields = append(fields, property+";")
+			}
+		}
+		return "{ " + strings.Join(fields, " ") + " }", nil
+	case *types.TypeParam:
+		return t.Obj().Name(), nil
+	case *types.Interface:
+		return "any", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// namedType returns the TypeScript type of a named type, declaring it if needed
+func (g *generator) namedType(named *types.Named, prepend func(string)) (string, error) {
+	obj := named.Obj()
+	// Predeclared types such as error have no package
+	if obj.Pkg() == nil {
+		return g.tsType(named.Underlying(), prepend)
+	}
+	if tsType, ok := mappedType(named, g.typeMappings); ok {
+		return tsType, nil
+	}
+	code, ok, err := g.declare(named)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return g.tsType(named.Underlying(), prepend)
+	}
+	prepend(code)
+
+	typeArgs := named.TypeArgs()
+	if typeArgs.Len() == 0 {
+		return obj.Name(), nil
+	}
+	args := make([]string, typeArgs.Len())
+	for i := range args {
+		if args[i], err = g.tsType(typeArgs.At(i), prepend); err != nil {
+			return "", err
+		}
+	}
+	return obj.Name() + "<" + strings.Join(args, ", ") + ">", nil
+}
+
+// arrayType returns the TypeScript array of elem, wrapping unions in parentheses
+func (g *generator) arrayType(elem types.Type, prepend func(string)) (string, error) {
+	tsType, err := g.tsType(elem, prepend)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(tsType, " | ") {
+		tsType = "(" + tsType + ")"
+	}
+	return tsType + "[]", nil
+}
+
+// basicType maps Go basic types to TypeScript
+// Types that failed to type check are converted to any
+func basicType(t *types.Basic) (string, error) {
+	info := t.Info()
+	switch {
+	case t.Kind() == types.Invalid:
+		return "any", nil
+	case info&types.IsBoolean != 0:
+		return "boolean", nil
+	case info&types.IsString != 0:
+		return "string", nil
+	case info&(types.IsInteger|types.IsFloat) != 0:
+		return "number", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// enumValues returns the JSON values of the exported constants declared with a named type, in declaration order
+func enumValues(named *types.Named) []any {
+	if _, ok := named.Underlying().(*types.Basic); !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+	scope := named.Obj().Pkg().Scope()
+	var consts []*types.Const
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if ok && c.Exported() && types.Identical(c.Type(), named) {
+			consts = append(consts, c)
+		}
+	}
+	slices.SortFunc(consts, func(a, b *types.Const) int {
+		return cmp.Compare(a.Pos(), b.Pos())
+	})
+	var values []any
+	for _, c := range consts {
+		value := constantValue(c.Val())
+		if value != nil && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// constantValue returns the JSON value of a constant
+func constantValue(value constant.Value) any {
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value)
+	case constant.Int:
+		return json.Number(value.ExactString())
+	case constant.Float:
+		f, _ := constant.Float64Val(value)
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+	case constant.Bool:
+		return constant.BoolVal(value)
+	}
+	return nil
+}
+
+// mappedType returns the TypeScript type of a named type that isn't converted from its definition:
+// types in typeMappings and types with custom JSON marshalers
+func mappedType(named *types.Named, typeMappings map[string]string) (string, bool) {
+	obj := named.Obj()
+	if tsType, ok := typeMappings[qualifiedName(obj)]; ok {
+		return tsType, true
+	}
+	// Custom marshalers decide the JSON encoding, not the fields
+	if hasMethod(named, "MarshalJSON") {
+		return "any", true
+	}
+	if hasMethod(named, "MarshalText") {
+		return "string", true
+	}
+	return "", false
+}
+
+// hasMethod reports whether a value or a pointer of type t has the named method
+func hasMethod(t types.Type, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), false, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+type structField struct {
+	v   *types.Var
+	tag string
+}
+
+// structFields returns the fields of a struct, with the fields of embedded structs promoted like encoding/json does
+func structFields(structType *types.Struct) []structField {
+	var fields []structField
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		tag := structType.Tag(i)
+		if field.Embedded() {
+			name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+			embedded := field.Type()
+			if pointer, ok := types.Unalias(embedded).(*types.Pointer); ok {
+				embedded = pointer.Elem()
+			}
+			if embeddedStruct, ok := embedded.Underlying().(*types.Struct); ok && name == "" {
+				fields = append(fields, structFields(embeddedStruct)...)
+				continue
+			}
+		}
+		fields = append(fields, structField{v: field, tag: tag})
+	}
+	return fields
+}
+
+// jsonField returns the property name and the options of a field as encoding/json marshals it
+func jsonField(field *types.Var, tag reflect.StructTag) (name, options string, ok bool) {
+	jsonTag := tag.Get("json")
+	if !field.Exported() || jsonTag == "-" {
+		return "", "", false
+	}
+	name, options, _ = strings.Cut(jsonTag, ",")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = field.Name()
+	}
+	return name, options, true
+}
+
+// property describes how a struct field is marshaled
+type property struct {
+	name     string
+	optional bool   // The property can be left out
+	nullable bool   // The property can be null
+	tsType   string // The TypeScript type set with the ts or ts_type tags
+	asString bool   // The string option encodes a number or a boolean as a JSON string
+}
+
+// parseProperty returns the property of a struct field, or false if the field isn't marshaled
+// Fields with omitempty or omitzero are optional and pointers without them are nullable.
+// The ts tag overrides the inferred property: `ts:"-"` leaves the field out, `ts:"Type"` sets its type
+// and the options optional, required and nullable change how the property is declared.
+func parseProperty(field structField) (property, bool) {
+	tag := reflect.StructTag(field.tag)
+	name, jsonOptions, ok := jsonField(field.v, tag)
+	tsTag := tag.Get("ts")
+	if !ok || tsTag == "-" {
+		return property{}, false
+	}
+	tsType, tsOptions, _ := strings.Cut(tsTag, ",")
+	if tsType == "" {
+		tsType = tag.Get("ts_type")
+	}
+	fieldType := types.Unalias(field.v.Type())
+	pointer, isPointer := fieldType.(*types.Pointer)
+	if isPointer {
+		fieldType = pointer.Elem()
+	}
+	basic, isBasic := fieldType.Underlying().(*types.Basic)
+	prop := property{
+		name:     name,
+		optional: hasOption(jsonOptions, "omitempty") || hasOption(jsonOptions, "omitzero"),
+		tsType:   tsType,
+		asString: isBasic && hasOption(jsonOptions, "string") && basic.Info()&(types.IsBoolean|types.IsNumeric) != 0,
+	}
+	prop.nullable = isPointer && !prop.optional
+	switch {
+	case hasOption(tsOptions, "optional"):
+		prop.optional = true
+	case hasOption(tsOptions, "required"):
+		prop.optional = false
+	}
+	if hasOption(tsOptions, "nullable") {
+		prop.nullable = true
+	}
+	return prop, true
+}
+
+// hasOption reports whether a comma-separated tag options list contains option
+func hasOption(options, option string) bool {
+	return slices.Contains(strings.Split(options, ","), option)
+}
+
+// isIdentifier reports whether name can be used as a TypeScript property name without quotes
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// loadCustomCode reads the code blocks added by hand to a previously generated file
+// Code between "//[Name:]" and "//[end]" is kept inside the interface Name when it's regenerated
+func loadCustomCode(fileName string) (map[string]string, error) {
+	result := make(map[string]string)
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, err
+	}
+	var currentName, currentValue string
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmedLine, "//[") && strings.HasSuffix(trimmedLine, ":]") {
+			currentName = strings.TrimSuffix(strings.TrimPrefix(trimmedLine, "//["), ":]")
+			currentValue = ""
+		} else if trimmedLine == "//[end]" {
+			result[currentName] = strings.TrimRight(currentValue, " \t\r\n")
+			currentName = ""
+			currentValue = ""
+		} else if currentName != "" {
+			currentValue += line + "\n"
+		}
+	}
+	return result, nil
+}
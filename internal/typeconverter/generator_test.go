@@ -0,0 +1,116 @@
+package typeconverter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	result, err := Generate(Config{
+		StructsFilePath:    "testdata/props.go",
+		GeneratedTypesPath: "testdata/generated.d.ts",
+		TypeMappings:       map[string]string{"time.Time": "Date"},
+	})
+	assert.Nil(t, err, "Generate should not return an error")
+	assert.Equal(t, `/* Do not change, this code is generated from Golang structs */
+
+
+export type Role = "admin" | "member";
+export type Level = 0 | 1;
+export interface Base {
+    id: number;
+}
+export interface User {
+    id: number;
+    name: string;
+    role: Role;
+    level: Level;
+    tags?: string[];
+    meta: {[key: string]: string};
+    createdAt: Date;
+    manager: User | null;
+    avatar?: string;
+    count: string;
+    note?: string | undefined;
+}
+export interface Page<T> {
+    items: T[];
+    total: number;
+}
+export interface PageProps {
+    users: Page<User>;
+    roles: Partial<Record<Role, boolean>>;
+    current: User | null;
+}`, result.Types)
+}
+
+func TestGenerate_Routes(t *testing.T) {
+	result, err := Generate(Config{
+		StructsFilePath: "testdata/props.go",
+		Routes:          map[string]string{"Users.tsx": "PageProps", "Home.tsx": "Unknown"},
+	})
+	assert.Nil(t, err, "Generate should not return an error")
+	assert.True(t, strings.HasSuffix(result.Types, `
+export interface Routes {
+    "Home.tsx": any;
+    "Users.tsx": PageProps;
+}`), "Routes should map page files to their props")
+}
+
+func TestGenerate_TypeError(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"props.go": "package props\n\ntype PageProps struct {\n\tUser Missing\n}\n",
+	})
+	_, err := Generate(Config{StructsFilePath: filepath.Join(dir, "props.go")})
+	if assert.Error(t, err, "Generate should return the type error") {
+		assert.Contains(t, err.Error(), "undefined: Missing")
+	}
+}
+
+func TestGenerate_NameCollision(t *testing.T) {
+	dir := writeModule(t, collidingPackages)
+	_, err := Generate(Config{StructsFilePath: filepath.Join(dir, "props.go")})
+	if assert.Error(t, err, "Generate should return the name collision") {
+		assert.Contains(t, err.Error(), "example.com/props/api.User and example.com/props/admin.User are both declared as User")
+	}
+}
+
+// collidingPackages is a props struct using two types of different packages with the same name
+var collidingPackages = map[string]string{
+	"api/user.go":   "package api\n\ntype User struct {\n\tName string `json:\"name\"`\n}\n",
+	"admin/user.go": "package admin\n\ntype User struct {\n\tRole string `json:\"role\"`\n}\n",
+	"props.go": `package props
+
+import (
+	"example.com/props/admin"
+	"example.com/props/api"
+)
+
+type PageProps struct {
+	User  api.User   ` + "`json:\"user\"`" + `
+	Admin admin.User ` + "`json:\"admin\"`" + `
+}
+`,
+}
+
+// writeModule writes the files of the module example.com/props to a temporary directory and returns it
+func writeModule(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/props\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,481 @@
+package typeconverter
+
+import (
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yejune/gotossr/internal/utils"
+)
+
+// generatedStructsHeader marks the Go structs as generated for linters and editors
+const generatedStructsHeader = "// Code generated by gossr from TypeScript types. DO NOT EDIT.\n\n"
+
+// goInitialisms are the words written in upper case in Go names
+var goInitialisms = map[string]bool{
+	"API": true, "CSS": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true, "UI": true, "URI": true, "URL": true,
+	"UUID": true, "XML": true,
+}
+
+// structsGenerator converts TypeScript interfaces and type aliases to Go types
+type structsGenerator struct {
+	decls   map[string]*tsDecl
+	imports map[string]bool
+}
+
+// generateStructs converts the exported interfaces and type aliases of the TypeScript files, and the types they
+// refer to, to Go structs with JSON tags in the package of outputPath
+func generateStructs(typeScriptFilesPath, outputPath string) (string, error) {
+	gen := &structsGenerator{decls: map[string]*tsDecl{}, imports: map[string]bool{}}
+	var decls []*tsDecl
+	for _, fp := range strings.Split(typeScriptFilesPath, ",") {
+		fp = strings.TrimSpace(fp)
+		if fp == "" {
+			continue
+		}
+		src, err := os.ReadFile(utils.GetFullFilePath(fp))
+		if err != nil {
+			return "", err
+		}
+		fileDecls, err := parseTypeScript(string(src))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", fp, err)
+		}
+		for _, decl := range fileDecls {
+			if _, ok := gen.decls[decl.name]; !ok {
+				gen.decls[decl.name] = decl
+				decls = append(decls, decl)
+			}
+		}
+	}
+
+	// Non-exported types are only converted if an exported type refers to them
+	used := map[string]bool{}
+	for _, decl := range decls {
+		if decl.exported {
+			gen.use(decl, used)
+		}
+	}
+
+	var body strings.Builder
+	for _, decl := range decls {
+		if used[decl.name] {
+			body.WriteString("\n" + gen.declare(decl))
+		}
+	}
+	if body.Len() == 0 {
+		return "", nil
+	}
+
+	var code strings.Builder
+	code.WriteString(generatedStructsHeader + "package " + goPackageName(utils.GetFullFilePath(outputPath)) + "\n")
+	if len(gen.imports) == 1 {
+		for path := range gen.imports {
+			code.WriteString("\nimport " + strconv.Quote(path) + "\n")
+		}
+	} else if len(gen.imports) > 1 {
+		code.WriteString("\nimport (\n")
+		for _, path := range slices.Sorted(maps.Keys(gen.imports)) {
+			code.WriteString(strconv.Quote(path) + "\n")
+		}
+		code.WriteString(")\n")
+	}
+	code.WriteString(body.String())
+	formatted, err := format.Source([]byte(code.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format the generated Go structs: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// use marks decl and the declarations it refers to as used
+func (gen *structsGenerator) use(decl *tsDecl, used map[string]bool) {
+	if used[decl.name] {
+		return
+	}
+	used[decl.name] = true
+	var walk func(t *tsType)
+	walk = func(t *tsType) {
+		if t == nil {
+			return
+		}
+		if t.kind == tsRef {
+			if ref, ok := gen.decls[t.name]; ok && !slices.Contains(decl.params, t.name) {
+				gen.use(ref, used)
+			}
+		}
+		for _, arg := range t.args {
+			walk(arg)
+		}
+		for _, member := range t.members {
+			walk(member.tsType)
+		}
+		walk(t.index)
+	}
+	for _, extended := range decl.extends {
+		walk(extended)
+	}
+	walk(decl.tsType)
+}
+
+// declare returns the Go declaration of a TypeScript interface or type alias
+func (gen *structsGenerator) declare(decl *tsDecl) string {
+	var code strings.Builder
+	code.WriteString(goComment(decl.doc))
+	name := goName(decl.name)
+	typeParams := ""
+	if len(decl.params) > 0 {
+		typeParams = "[" + strings.Join(decl.params, ", ") + " any]"
+	}
+
+	// Unions of strings become string types with a constant for each value
+	if values, ok := stringLiterals(decl.tsType); ok && len(decl.params) == 0 {
+		fmt.Fprintf(&code, "type %s string\n\nconst (\n", name)
+		var names []string
+		for _, value := range values {
+			constName := name + goName(value)
+			if slices.Contains(names, constName) {
+				continue
+			}
+			names = append(names, constName)
+			fmt.Fprintf(&code, "%s %s = %s\n", constName, name, strconv.Quote(value))
+		}
+		code.WriteString(")\n")
+		return code.String()
+	}
+
+	if len(decl.extends) > 0 || decl.tsType.kind != tsRef && gen.isStruct(decl.tsType) {
+		fmt.Fprintf(&code, "type %s%s struct {\n", name, typeParams)
+		for _, extended := range decl.extends {
+			code.WriteString(gen.embed(extended, decl.params))
+		}
+		code.WriteString(gen.structFields(decl.tsType, decl.params))
+		code.WriteString("}\n")
+		return code.String()
+	}
+
+	goType, _ := gen.goType(decl.tsType, decl.params)
+	if typeParams != "" {
+		fmt.Fprintf(&code, "type %s%s %s\n", name, typeParams, goType)
+	} else {
+		fmt.Fprintf(&code, "type %s = %s\n", name, goType)
+	}
+	return code.String()
+}
+
+// isStruct reports whether a type is converted to a struct
+func (gen *structsGenerator) isStruct(t *tsType) bool {
+	return gen.resolvesToStruct(t, map[string]bool{})
+}
+
+// resolvesToStruct reports whether a type is converted to a struct, following aliases that weren't seen yet
+func (gen *structsGenerator) resolvesToStruct(t *tsType, seen map[string]bool) bool {
+	switch t.kind {
+	case tsObject:
+		return len(t.members) > 0 || t.index == nil
+	case tsIntersection:
+		return true
+	case tsRef:
+		if t.name == "Partial" || t.name == "Required" || t.name == "Readonly" {
+			return len(t.args) == 1 && gen.resolvesToStruct(t.args[0], seen)
+		}
+		decl, ok := gen.decls[t.name]
+		if !ok || seen[t.name] {
+			return false
+		}
+		seen[t.name] = true
+		return len(decl.extends) > 0 || gen.resolvesToStruct(decl.tsType, seen)
+	}
+	return false
+}
+
+// structFields returns the fields of the struct converted from an object or intersection type
+func (gen *structsGenerator) structFields(t *tsType, params []string) string {
+	switch t.kind {
+	case tsIntersection:
+		var fields strings.Builder
+		for _, arg := range t.args {
+			if arg.kind == tsObject {
+				fields.WriteString(gen.structFields(arg, params))
+			} else {
+				fields.WriteString(gen.embed(arg, params))
+			}
+		}
+		return fields.String()
+	case tsObject:
+		var fields strings.Builder
+		for _, member := range t.members {
+			// Functions aren't part of JSON
+			if nonNullable(member.tsType).name != "function" {
+				fields.WriteString(gen.field(member, params))
+			}
+		}
+		return fields.String()
+	}
+	return gen.embed(t, params)
+}
+
+// embed returns the embedded field of an extended interface, whose fields encoding/json flattens
+func (gen *structsGenerator) embed(t *tsType, params []string) string {
+	goType, _ := gen.goType(t, params)
+	if t.kind != tsRef || !gen.isStruct(t) {
+		return "// " + goType + " can't be embedded\n"
+	}
+	return goType + "\n"
+}
+
+// field returns the struct field of an object type member
+func (gen *structsGenerator) field(member *tsMember, params []string) string {
+	goType, nullable := gen.goType(member.tsType, params)
+	optional := member.optional || isOptional(member.tsType)
+	pointer := nullable || optional && gen.isStruct(nonNullable(member.tsType))
+	if pointer && !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") && goType != "any" {
+		goType = "*" + goType
+	}
+	tag := member.name
+	if optional {
+		tag += ",omitempty"
+	}
+	return fmt.Sprintf("%s%s %s `json:%s`\n", goComment(member.doc), goName(member.name), goType, strconv.Quote(tag))
+}
+
+// goType returns the Go type of a TypeScript type, and whether null is one of its values
+func (gen *structsGenerator) goType(t *tsType, params []string) (string, bool) {
+	switch t.kind {
+	case tsKeyword:
+		switch t.name {
+		case "string":
+			return "string", false
+		case "number":
+			return "float64", false
+		case "bigint":
+			return "int64", false
+		case "boolean":
+			return "bool", false
+		case "object":
+			return "map[string]any", false
+		case "null":
+			return "any", true
+		}
+		return "any", false
+	case tsString:
+		return "string", false
+	case tsNumber:
+		return "float64", false
+	case tsArray:
+		elem, _ := gen.goType(t.args[0], params)
+		return "[]" + elem, false
+	case tsTuple:
+		return "[]any", false
+	case tsObject:
+		if len(t.members) == 0 && t.index != nil {
+			value, _ := gen.goType(t.index, params)
+			return "map[string]" + value, false
+		}
+		return "struct {\n" + gen.structFields(t, params) + "}", false
+	case tsIntersection:
+		return "struct {\n" + gen.structFields(t, params) + "}", false
+	case tsUnion:
+		return gen.unionType(t, params)
+	}
+	return gen.refType(t, params), false
+}
+
+// unionType returns the Go type of a union, any unless its members have the same Go type
+func (gen *structsGenerator) unionType(t *tsType, params []string) (string, bool) {
+	var goTypes []string
+	nullable := false
+	for _, arg := range t.args {
+		if arg.kind == tsKeyword && (arg.name == "null" || arg.name == "undefined") {
+			nullable = nullable || arg.name == "null"
+			continue
+		}
+		goType, argNullable := gen.goType(arg, params)
+		nullable = nullable || argNullable
+		if !slices.Contains(goTypes, goType) {
+			goTypes = append(goTypes, goType)
+		}
+	}
+	if len(goTypes) != 1 {
+		return "any", nullable
+	}
+	return goTypes[0], nullable
+}
+
+// refType returns the Go type of a reference to a type parameter, a declared type or a built-in type
+func (gen *structsGenerator) refType(t *tsType, params []string) string {
+	if slices.Contains(params, t.name) {
+		return t.name
+	}
+	var args []string
+	for _, arg := range t.args {
+		goType, _ := gen.goType(arg, params)
+		args = append(args, goType)
+	}
+	if decl, ok := gen.decls[t.name]; ok {
+		if len(decl.params) == 0 {
+			return goName(t.name)
+		}
+		// Missing type arguments fall back to their defaults, which aren't kept
+		for len(args) < len(decl.params) {
+			args = append(args, "any")
+		}
+		return goName(t.name) + "[" + strings.Join(args[:len(decl.params)], ", ") + "]"
+	}
+	switch t.name {
+	case "Array", "ReadonlyArray", "Set", "ReadonlySet":
+		if len(args) == 1 {
+			return "[]" + args[0]
+		}
+	case "Record", "Map", "ReadonlyMap":
+		if len(args) == 2 {
+			return "map[string]" + args[1]
+		}
+	case "Partial", "Required", "Readonly", "NonNullable":
+		if len(args) == 1 {
+			return args[0]
+		}
+	case "Date":
+		gen.imports["time"] = true
+		return "time.Time"
+	}
+	// Types imported from other modules are unknown
+	return "any"
+}
+
+// nonNullable returns a type without null and undefined
+func nonNullable(t *tsType) *tsType {
+	if t.kind != tsUnion {
+		return t
+	}
+	var args []*tsType
+	for _, arg := range t.args {
+		if arg.kind != tsKeyword || arg.name != "null" && arg.name != "undefined" {
+			args = append(args, arg)
+		}
+	}
+	if len(args) == 1 {
+		return args[0]
+	}
+	return &tsType{kind: tsUnion, args: args}
+}
+
+// isOptional reports whether undefined is one of the values of a type
+func isOptional(t *tsType) bool {
+	return t.kind == tsUnion && slices.ContainsFunc(t.args, func(arg *tsType) bool {
+		return arg.kind == tsKeyword && arg.name == "undefined"
+	})
+}
+
+// stringLiterals returns the values of a union of string literals
+func stringLiterals(t *tsType) ([]string, bool) {
+	if t.kind == tsString {
+		return []string{t.name}, true
+	}
+	if t.kind != tsUnion {
+		return nil, false
+	}
+	var values []string
+	for _, arg := range t.args {
+		if arg.kind != tsString {
+			return nil, false
+		}
+		values = append(values, arg.name)
+	}
+	return values, true
+}
+
+// goName converts a TypeScript name or string value to an exported Go name, e.g. userId to UserID
+func goName(name string) string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word = nil
+			continue
+		}
+		// A word starts at an upper case letter after a lower case one, or before a lower case one in an acronym
+		if len(word) > 0 && unicode.IsUpper(r) {
+			previous := word[len(word)-1]
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(previous) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	var result strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); goInitialisms[upper] {
+			result.WriteString(upper)
+			continue
+		}
+		wordRunes := []rune(w)
+		result.WriteString(string(unicode.ToUpper(wordRunes[0])) + string(wordRunes[1:]))
+	}
+	goName := result.String()
+	if goName == "" || unicode.IsDigit([]rune(goName)[0]) {
+		goName = "X" + goName
+	}
+	return goName
+}
+
+// goComment converts a JSDoc comment to Go comment lines
+func goComment(doc string) string {
+	var lines []string
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if line != "" || len(lines) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var comment strings.Builder
+	for _, line := range lines {
+		comment.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	return comment.String()
+}
+
+// goPackageName returns the package of the Go files in the directory of outputPath, or the name of the directory
+func goPackageName(outputPath string) string {
+	dir := filepath.Dir(outputPath)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		if file == outputPath || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		if f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly); err == nil {
+			return f.Name.Name
+		}
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(dir))
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		return "types"
+	}
+	return name
+}
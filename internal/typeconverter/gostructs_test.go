@@ -0,0 +1,72 @@
+package typeconverter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate_Structs(t *testing.T) {
+	result, err := Generate(Config{
+		TypeScriptFilesPath: "testdata/requests.ts",
+		GoStructsPath:       "testdata/requests.go",
+	})
+	assert.Nil(t, err, "Generate should not return an error")
+	assert.Equal(t, "// Code generated by gossr from TypeScript types. DO NOT EDIT.\n"+`
+package testdata
+
+import "time"
+
+type Plan string
+
+const (
+	PlanFree Plan = "free"
+	PlanPro  Plan = "pro"
+)
+
+type Address struct {
+	Street string  `+"`json:\"street\"`"+`
+	Zip    *string `+"`json:\"zip,omitempty\"`"+`
+}
+
+// Payload of the signup form
+type SignupForm struct {
+	Contact
+	UserName    string             `+"`json:\"userName\"`"+`
+	Age         float64            `+"`json:\"age\"`"+`
+	Plan        Plan               `+"`json:\"plan\"`"+`
+	Tags        []string           `+"`json:\"tags\"`"+`
+	Address     *Address           `+"`json:\"address,omitempty\"`"+`
+	Billing     *Address           `+"`json:\"billing\"`"+`
+	AcceptTerms bool               `+"`json:\"accept-terms\"`"+`
+	CreatedAt   time.Time          `+"`json:\"createdAt\"`"+`
+	Extra       map[string]float64 `+"`json:\"extra\"`"+`
+	Session     any                `+"`json:\"session\"`"+`
+}
+
+type Contact struct {
+	Email string `+"`json:\"email\"`"+`
+}
+
+type Paged[T any] struct {
+	Items []T     `+"`json:\"items\"`"+`
+	Total float64 `+"`json:\"total\"`"+`
+}
+
+type SignupPage = Paged[SignupForm]
+`, result.Structs)
+	assert.Equal(t, "", result.Types, "No TypeScript types should be generated without structs")
+}
+
+func TestGoName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"userId":     "UserID",
+		"avatarURL":  "AvatarURL",
+		"APIKey":     "APIKey",
+		"first-name": "FirstName",
+		"in_review":  "InReview",
+		"2fa":        "X2fa",
+	} {
+		assert.Equal(t, expected, goName(name), name)
+	}
+}
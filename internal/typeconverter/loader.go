@@ -0,0 +1,56 @@
+package typeconverter
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loader type checks the packages of the props structs with go/packages
+// Imports are resolved by the go command like in builds, so the go toolchain and the module's dependencies must be
+// available. Packages are cached by directory.
+type loader struct {
+	packages map[string]*types.Package
+}
+
+func newLoader() *loader {
+	return &loader{packages: make(map[string]*types.Package)}
+}
+
+// loadDir type checks the package in dir, which doesn't need to be importable (e.g. package main)
+// Errors of the package or its imports are returned, rather than converting the types they affect to any.
+func (l *loader) loadDir(dir string) (*types.Package, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if pkg, ok := l.packages[dir]; ok {
+		return pkg, nil
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+	}, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package in %s: %w", dir, err)
+	}
+	var errs []error
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, pkgErr := range pkg.Errors {
+			errs = append(errs, pkgErr)
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to type check package in %s: %w", dir, err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	l.packages[dir] = pkgs[0].Types
+	return pkgs[0].Types, nil
+}
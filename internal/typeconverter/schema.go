@@ -0,0 +1,191 @@
+package typeconverter
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+const schemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe the JSON encoding of the props structs
+// An empty schema accepts any value
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // false or a *Schema
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+
+	propertyOrder []string // Properties in declaration order
+	defsOrder     []string // Defs in declaration order
+}
+
+// Def returns the definition of a props struct or nil if there is none
+func (s *Schema) Def(name string) *Schema {
+	if s == nil {
+		return nil
+	}
+	return s.Defs[name]
+}
+
+// schemaBuilder builds the JSON Schema definitions of Go types, following the same rules as the TypeScript generator
+// Named structs and enums are definitions, generic structs are inlined with their type arguments
+type schemaBuilder struct {
+	root         *Schema
+	defined      map[string]*types.TypeName // Defined types by definition name, which doesn't include the package
+	inlining     map[*types.Named]bool
+	typeMappings map[string]string
+	err          error // The first name collision between definitions
+}
+
+func newSchemaBuilder(typeMappings map[string]string) *schemaBuilder {
+	return &schemaBuilder{
+		root:         &Schema{Schema: schemaVersion, Defs: make(map[string]*Schema)},
+		defined:      make(map[string]*types.TypeName),
+		inlining:     make(map[*types.Named]bool),
+		typeMappings: typeMappings,
+	}
+}
+
+// define adds the definition of a named type, it returns false if the type has no definition of its own
+// Types of different packages with the same name can't share a definition, the collision is kept in err
+func (b *schemaBuilder) define(named *types.Named) bool {
+	obj := named.Obj()
+	name := obj.Name()
+	if other, ok := b.defined[name]; ok {
+		if other != obj && b.err == nil {
+			b.err = fmt.Errorf("%s and %s are both defined as %s, rename one of them", qualifiedName(other), qualifiedName(obj), name)
+		}
+		return other == obj
+	}
+	if named.TypeParams().Len() > 0 {
+		return false
+	}
+	if structType, ok := named.Underlying().(*types.Struct); ok {
+		// Add the definition before building it so that recursive types refer to it
+		def := &Schema{}
+		b.add(obj, def)
+		*def = *b.structSchema(structType)
+		return true
+	}
+	values := enumValues(named)
+	if len(values) == 0 {
+		return false
+	}
+	def := b.schema(named.Underlying())
+	def.Enum = values
+	b.add(obj, def)
+	return true
+}
+
+// add adds the definition of a type to the root schema
+func (b *schemaBuilder) add(obj *types.TypeName, def *Schema) {
+	b.defined[obj.Name()] = obj
+	b.root.Defs[obj.Name()] = def
+	b.root.defsOrder = append(b.root.defsOrder, obj.Name())
+}
+
+// schema returns the JSON Schema of t
+func (b *schemaBuilder) schema(t types.Type) *Schema {
+	switch t := types.Unalias(t).(type) {
+	case *types.Pointer:
+		return b.schema(t.Elem())
+	case *types.Named:
+		return b.namedSchema(t)
+	case *types.Basic:
+		info := t.Info()
+		switch {
+		case info&types.IsBoolean != 0:
+			return &Schema{Type: "boolean"}
+		case info&types.IsString != 0:
+			return &Schema{Type: "string"}
+		case info&types.IsInteger != 0:
+			return &Schema{Type: "integer"}
+		case info&types.IsFloat != 0:
+			return &Schema{Type: "number"}
+		}
+	case *types.Slice:
+		// []byte is encoded as a base64 string
+		if elem, ok := t.Elem().Underlying().(*types.Basic); ok && elem.Kind() == types.Byte {
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case *types.Array:
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case *types.Map:
+		schema := &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+		if key := b.schema(t.Key()); key.Ref != "" || key.Enum != nil {
+			schema.PropertyNames = key
+		}
+		return schema
+	case *types.Struct:
+		return b.structSchema(t)
+	}
+	return &Schema{}
+}
+
+// namedSchema returns a reference to the definition of a named type, or its schema if it has none
+func (b *schemaBuilder) namedSchema(named *types.Named) *Schema {
+	if named.Obj().Pkg() == nil {
+		return b.schema(named.Underlying())
+	}
+	if tsType, ok := mappedType(named, b.typeMappings); ok {
+		return primitiveSchema(tsType)
+	}
+	if b.define(named) {
+		return &Schema{Ref: "#/$defs/" + named.Obj().Name()}
+	}
+	// Instances of recursive generic structs can't be inlined forever
+	if b.inlining[named] {
+		return &Schema{}
+	}
+	b.inlining[named] = true
+	defer delete(b.inlining, named)
+	return b.schema(named.Underlying())
+}
+
+// structSchema returns the schema of a struct, which doesn't allow properties that the struct doesn't marshal
+func (b *schemaBuilder) structSchema(structType *types.Struct) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	for _, field := range structFields(structType) {
+		prop, ok := parseProperty(field)
+		if !ok {
+			continue
+		}
+		var propSchema *Schema
+		switch {
+		case prop.tsType != "":
+			propSchema = primitiveSchema(prop.tsType)
+		case prop.asString:
+			propSchema = &Schema{Type: "string"}
+		default:
+			propSchema = b.schema(field.v.Type())
+		}
+		if prop.nullable {
+			propSchema = &Schema{AnyOf: []*Schema{propSchema, {Type: "null"}}}
+		}
+		schema.Properties[prop.name] = propSchema
+		schema.propertyOrder = append(schema.propertyOrder, prop.name)
+		if !prop.optional {
+			schema.Required = append(schema.Required, prop.name)
+		}
+	}
+	return schema
+}
+
+// primitiveSchema returns the schema of a TypeScript type set by a mapping or a tag
+// Types other than primitives accept any value
+func primitiveSchema(tsType string) *Schema {
+	switch strings.TrimSpace(tsType) {
+	case "string", "number", "boolean", "null":
+		return &Schema{Type: tsType}
+	}
+	return &Schema{}
+}
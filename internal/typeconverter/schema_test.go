@@ -0,0 +1,49 @@
+package typeconverter
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	result, err := Generate(Config{StructsFilePath: "testdata/props.go"})
+	assert.Nil(t, err, "Generate should not return an error")
+
+	problems, err := result.Schema.Validate("PageProps", []byte(`{
+		"users": {"items": [{"id": 1, "name": "a", "role": "admin", "level": 1, "meta": null, "createdAt": "2024-01-01T00:00:00Z", "manager": null, "count": "3"}], "total": 1},
+		"roles": {"member": true},
+		"current": null
+	}`))
+	assert.Nil(t, err, "Validate should not return an error")
+	assert.Empty(t, problems, "Props marshaled from the structs should match the schema")
+
+	problems, err = result.Schema.Validate("PageProps", []byte(`{
+		"users": {"items": [{"id": 1, "name": "a", "role": "owner", "level": 1, "meta": {}, "createdAt": "", "manager": null, "count": "3", "email": ""}], "total": 1.5},
+		"roles": {}
+	}`))
+	assert.Nil(t, err, "Validate should not return an error")
+	assert.Equal(t, []string{
+		`PageProps: missing property "current"`,
+		`PageProps.users.items[0]: unexpected property "email"`,
+		`PageProps.users.items[0].role: "owner" is not one of the constants`,
+		`PageProps.users.total: expected integer, got 1.5`,
+	}, problems)
+}
+
+func TestSchema_NameCollision(t *testing.T) {
+	dir := writeModule(t, collidingPackages)
+	pkg, err := newLoader().loadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	named := pkg.Scope().Lookup("PageProps").Type().(*types.Named)
+	schemas := newSchemaBuilder(DefaultTypeMappings)
+	schemas.define(named)
+	if assert.Error(t, schemas.err, "define should keep the name collision") {
+		assert.Equal(t, "example.com/props/api.User and example.com/props/admin.User are both defined as User, rename one of them", schemas.err.Error())
+	}
+	assert.Equal(t, "#/$defs/User", schemas.root.Defs["PageProps"].Properties["user"].Ref, "The first type should keep the definition")
+	assert.Empty(t, schemas.root.Defs["PageProps"].Properties["admin"].Ref, "The colliding type shouldn't refer to the definition of the first one")
+}
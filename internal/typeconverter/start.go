@@ -1,62 +1,173 @@
 package typeconverter
 
 import (
-	"os/exec"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/yejune/gotossr/internal/utils"
-
-	_ "github.com/tkrajina/typescriptify-golang-structs/typescriptify"
 )
 
+// Config is the config of the type converter
+type Config struct {
+	StructsFilePath    string            // The path to the Go structs file(s), comma-separated for multiple files
+	GeneratedTypesPath string            // The path to write the TypeScript types to
+	SchemaPath         string            // The path to write the JSON Schema to, skipped if empty
+	ZodPath            string            // The path to write the Zod schemas to, skipped if empty
+	TypeMappings       map[string]string // TypeScript types of Go types (e.g. "github.com/google/uuid.UUID"), on top of DefaultTypeMappings
+	Routes             map[string]string // Props struct names by page file, emitted as the Routes interface
+
+	TypeScriptFilesPath string // The path to the TypeScript file(s) to convert to Go structs, comma-separated
+	GoStructsPath       string // The path to write the Go structs to, in the package of its directory
+}
+
+// Result is the output of the type converter
+type Result struct {
+	Types  string  // The TypeScript types, empty if there are no structs to convert
+	Schema *Schema // The JSON Schema, with a definition for each struct and enum
+	Zod    string  // The Zod schemas, empty if Config.ZodPath is not set
+
+	Structs string // The Go structs converted from the TypeScript types, empty if there are none
+}
+
 // Start starts the type converter
-// It gets the name of structs in PropsStructsPath and generates a temporary file to run the type converter
-// structsFilePath can be comma-separated for multiple files
-func Start(structsFilePath, generatedTypesPath string) error {
-	// Split by comma for multiple files
-	filePaths := strings.Split(structsFilePath, ",")
+// It converts the structs in StructsFilePath to TypeScript and writes them to GeneratedTypesPath,
+// along with the JSON Schema and Zod schemas if their paths are set.
+// The TypeScript types in TypeScriptFilesPath are converted to Go structs written to GoStructsPath.
+func Start(config Config) (*Schema, error) {
+	result, err := Generate(config)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := result.outputs(config)
+	if err != nil {
+		return nil, err
+	}
+	for _, output := range outputs {
+		if err := os.WriteFile(utils.GetFullFilePath(output.path), []byte(output.contents), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return result.Schema, nil
+}
 
-	var allStructNames []string
-	var firstFilePath string
+type output struct {
+	path     string
+	contents string
+}
 
-	for _, fp := range filePaths {
+// outputs returns the contents of the files configured in config
+func (result Result) outputs(config Config) ([]output, error) {
+	var outputs []output
+	if result.Structs != "" {
+		outputs = append(outputs, output{config.GoStructsPath, result.Structs})
+	}
+	if result.Types == "" {
+		return outputs, nil
+	}
+	outputs = append(outputs, output{config.GeneratedTypesPath, result.Types})
+	if config.SchemaPath != "" {
+		schema, err := json.MarshalIndent(result.Schema, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, output{config.SchemaPath, string(schema) + "\n"})
+	}
+	if config.ZodPath != "" {
+		outputs = append(outputs, output{config.ZodPath, result.Zod})
+	}
+	return outputs, nil
+}
+
+// Generate returns the outputs of the type converter without writing them
+// Custom code blocks in the existing GeneratedTypesPath are kept
+func Generate(config Config) (Result, error) {
+	result, err := generateTypes(config)
+	if err != nil {
+		return Result{}, err
+	}
+	if config.TypeScriptFilesPath != "" && config.GoStructsPath != "" {
+		if result.Structs, err = generateStructs(config.TypeScriptFilesPath, config.GoStructsPath); err != nil {
+			return Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// generateTypes converts the structs in StructsFilePath to TypeScript
+func generateTypes(config Config) (Result, error) {
+	var typeLoader *loader
+	var gen *generator
+	var schemas *schemaBuilder
+	var declarations strings.Builder
+	for _, fp := range strings.Split(config.StructsFilePath, ",") {
 		fp = strings.TrimSpace(fp)
 		if fp == "" {
 			continue
 		}
-		if firstFilePath == "" {
-			firstFilePath = fp
-		}
 
-		// Get struct names from file
+		// Get type names from file
 		structNames, err := getStructNamesFromFile(fp)
 		if err != nil {
-			return err
+			return Result{}, err
+		}
+		if len(structNames) == 0 {
+			continue
 		}
-		allStructNames = append(allStructNames, structNames...)
-	}
 
-	if len(allStructNames) == 0 {
-		return nil
+		if gen == nil {
+			customCode := map[string]string{}
+			if config.GeneratedTypesPath != "" {
+				if customCode, err = loadCustomCode(utils.GetFullFilePath(config.GeneratedTypesPath)); err != nil {
+					return Result{}, err
+				}
+			}
+			typeLoader = newLoader()
+			gen = newGenerator(customCode, config.TypeMappings)
+			schemas = newSchemaBuilder(gen.typeMappings)
+		}
+		pkg, err := typeLoader.loadDir(filepath.Dir(utils.GetFullFilePath(fp)))
+		if err != nil {
+			return Result{}, err
+		}
+		for _, structName := range structNames {
+			typeName, ok := pkg.Scope().Lookup(structName).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			named, ok := types.Unalias(typeName.Type()).(*types.Named)
+			if !ok {
+				continue
+			}
+			code, _, err := gen.declare(named)
+			if err != nil {
+				return Result{}, fmt.Errorf("%s: %w", fp, err)
+			}
+			if code != "" {
+				declarations.WriteString("\n" + strings.Trim(code, " \t\r\n"))
+			}
+			schemas.define(named)
+			if schemas.err != nil {
+				return Result{}, fmt.Errorf("%s: %w", fp, schemas.err)
+			}
+		}
 	}
 
-	// Create a folder for the temporary generator files
-	cacheDir, err := utils.GetTypeConverterCacheDir()
-	if err != nil {
-		return err
+	if declarations.Len() == 0 {
+		return Result{}, nil
 	}
-
-	// Create the generator file (using first file path for package resolution)
-	temporaryFilePath, err := createTemporaryFileMulti(filePaths, generatedTypesPath, cacheDir, allStructNames)
-	if err != nil {
-		return err
+	if len(config.Routes) > 0 {
+		declarations.WriteString("\n" + gen.routes(config.Routes))
 	}
-
-	// Run the file
-	cmd := exec.Command("go", "run", temporaryFilePath)
-	_, err = cmd.CombinedOutput()
-	if err != nil {
-		return err
+	result := Result{
+		Types:  generatedHeader + declarations.String(),
+		Schema: schemas.root,
+	}
+	if config.ZodPath != "" {
+		result.Zod = zodSchemas(schemas.root)
 	}
-	return nil
+	return result, nil
 }
@@ -0,0 +1,49 @@
+package testdata
+
+import "time"
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+)
+
+type Level int
+
+const (
+	LevelLow Level = iota
+	LevelHigh
+)
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+type User struct {
+	Base
+	Name      string            `json:"name"`
+	Role      Role              `json:"role"`
+	Level     Level             `json:"level"`
+	Tags      []string          `json:"tags,omitempty"`
+	Meta      map[string]string `json:"meta"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Manager   *User             `json:"manager"`
+	Avatar    *string           `json:"avatar,omitempty"`
+	Count     int64             `json:"count,string"`
+	Internal  string            `ts:"-"`
+	Note      string            `json:"note" ts:"string | undefined,optional"`
+	Hidden    string            `json:"-"`
+	secret    string
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+type PageProps struct {
+	Users   Page[User]    `json:"users"`
+	Roles   map[Role]bool `json:"roles"`
+	Current *User         `json:"current"`
+}
@@ -0,0 +1,32 @@
+import type { Session } from "./session";
+
+export type Plan = "free" | "pro";
+
+type Address = {
+  street: string;
+  zip?: string | null;
+};
+
+/** Payload of the signup form */
+export interface SignupForm extends Contact {
+  userName: string;
+  age: number;
+  plan: Plan;
+  tags: string[];
+  address?: Address;
+  billing: Address | null;
+  "accept-terms": boolean;
+  createdAt: Date;
+  extra: { [key: string]: number };
+  session: Session;
+  onSubmit?: (form: SignupForm) => void;
+}
+
+export interface Contact {
+  email: string;
+}
+
+export type Paged<T> = { items: T[]; total: number };
+export type SignupPage = Paged<SignupForm>;
+
+function unused(): void {}
@@ -0,0 +1,557 @@
+package typeconverter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tsDecl is an interface or type alias declared in a TypeScript file
+type tsDecl struct {
+	name     string
+	exported bool
+	doc      string
+	params   []string  // Type parameter names
+	extends  []*tsType // Extended interfaces
+	tsType   *tsType   // The aliased type, or the object type of an interface
+}
+
+// tsMember is a property of an interface or object type
+type tsMember struct {
+	name     string
+	optional bool
+	doc      string
+	tsType   *tsType
+}
+
+type tsKind int
+
+const (
+	tsKeyword      tsKind = iota // string, number, boolean, any... and function for function types
+	tsString                     // String literal
+	tsNumber                     // Number literal
+	tsRef                        // Reference to a named type, with type arguments
+	tsArray                      // Array of args[0]
+	tsTuple                      // Tuple of args
+	tsObject                     // Object type with members and an index signature
+	tsUnion                      // Union of args
+	tsIntersection               // Intersection of args
+)
+
+// tsType is a TypeScript type expression
+type tsType struct {
+	kind    tsKind
+	name    string // Keyword, literal text or referenced name
+	args    []*tsType
+	members []*tsMember
+	index   *tsType // Value type of the index signature of an object type
+}
+
+// tsToken is a token of a TypeScript file
+type tsToken struct {
+	kind    byte   // 'i' identifier, 's' string, 'n' number, 'p' punctuation, 0 end of file
+	text    string // The token, strings are unquoted
+	doc     string // The JSDoc comment right before the token
+	newline bool   // The token starts a line
+	line    int
+}
+
+// tokenizeTypeScript splits TypeScript source into tokens, dropping comments other than JSDoc
+func tokenizeTypeScript(src string) ([]tsToken, error) {
+	var tokens []tsToken
+	var doc string
+	newline := true
+	line, counted := 1, 0
+	var i int
+	emit := func(kind byte, text string) {
+		line += strings.Count(src[counted:i], "\n")
+		counted = i
+		tokens = append(tokens, tsToken{kind: kind, text: text, doc: doc, newline: newline, line: line})
+		doc, newline = "", false
+	}
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			newline = true
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line+strings.Count(src[counted:i], "\n"))
+			}
+			if strings.HasPrefix(src[i:], "/**") {
+				doc = src[i+3 : i+2+end]
+			}
+			i += end + 4
+		case c == '"' || c == '\'' || c == '`':
+			var text strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				text.WriteByte(src[j])
+			}
+			if j == len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", line+strings.Count(src[counted:i], "\n"))
+			}
+			kind := byte('s')
+			if c == '`' {
+				// Template literal types are only known to be strings
+				kind = 'i'
+				text.Reset()
+				text.WriteString("string")
+			}
+			emit(kind, text.String())
+			i = j + 1
+		case isIdentByte(c) || c >= 0x80:
+			j := i
+			for j < len(src) && (isIdentByte(src[j]) || src[j] >= '0' && src[j] <= '9' || src[j] >= 0x80) {
+				j++
+			}
+			emit('i', src[i:j])
+			i = j
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i
+			for j < len(src) && (src[j] == '.' || src[j] == '_' || isIdentByte(src[j]) || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			emit('n', strings.ReplaceAll(src[i:j], "_", ""))
+			i = j
+		default:
+			text := string(c)
+			for _, punct := range []string{"...", "=>"} {
+				if strings.HasPrefix(src[i:], punct) {
+					text = punct
+				}
+			}
+			emit('p', text)
+			i += len(text)
+		}
+	}
+	i = len(src)
+	emit(0, "")
+	tokens[len(tokens)-1].newline = true
+	return tokens, nil
+}
+
+func isIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
+
+// tsParser parses the interfaces and type aliases of a TypeScript file, skipping other statements
+type tsParser struct {
+	tokens []tsToken
+	pos    int
+}
+
+// parseTypeScript returns the interfaces and type aliases declared in TypeScript source
+func parseTypeScript(src string) (decls []*tsDecl, err error) {
+	tokens, err := tokenizeTypeScript(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &tsParser{tokens: tokens}
+	defer func() {
+		if r := recover(); r != nil {
+			parseErr, ok := r.(tsParseError)
+			if !ok {
+				panic(r)
+			}
+			err = parseErr
+		}
+	}()
+	for p.peek().kind != 0 {
+		start := p.pos
+		doc := p.peek().doc
+		exported := p.accept("export")
+		p.accept("declare")
+		switch {
+		case p.peek().text == "interface" && p.peekAt(1).kind == 'i':
+			p.next()
+			decl := p.parseInterface()
+			decl.exported, decl.doc = exported, doc
+			decls = append(decls, decl)
+		case p.peek().text == "type" && p.peekAt(1).kind == 'i':
+			p.next()
+			decl := p.parseAlias()
+			decl.exported, decl.doc = exported, doc
+			decls = append(decls, decl)
+		default:
+			p.pos = start
+			p.skipStatement()
+		}
+	}
+	return decls, nil
+}
+
+type tsParseError string
+
+func (e tsParseError) Error() string {
+	return string(e)
+}
+
+func (p *tsParser) peek() tsToken {
+	return p.peekAt(0)
+}
+
+func (p *tsParser) peekAt(offset int) tsToken {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *tsParser) next() tsToken {
+	token := p.peek()
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return token
+}
+
+// accept consumes the next token if it is text, which must not be a string literal
+func (p *tsParser) accept(text string) bool {
+	if p.peek().kind != 's' && p.peek().text == text {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *tsParser) expect(text string) {
+	if !p.accept(text) {
+		p.fail("expected %q, found %q", text, p.peek().text)
+	}
+}
+
+func (p *tsParser) fail(format string, args ...any) {
+	panic(tsParseError(fmt.Sprintf("line %d: ", p.peek().line) + fmt.Sprintf(format, args...)))
+}
+
+func (p *tsParser) ident() string {
+	token := p.next()
+	if token.kind != 'i' {
+		p.fail("expected an identifier, found %q", token.text)
+	}
+	return token.text
+}
+
+// skipStatement skips a statement that doesn't declare types, e.g. an import or a function
+func (p *tsParser) skipStatement() {
+	depth := 0
+	for first := true; p.peek().kind != 0; first = false {
+		token := p.peek()
+		// Statements without semicolons end at the next line starting a declaration
+		if depth == 0 && !first && token.newline && token.kind == 'i' {
+			switch token.text {
+			case "export", "import", "interface", "type", "declare", "const", "let", "function", "class", "enum":
+				return
+			}
+		}
+		p.next()
+		if token.kind != 'p' {
+			continue
+		}
+		switch token.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth <= 0 && token.text == "}" {
+				return
+			}
+		case ";":
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// parseInterface parses an interface declaration after the interface keyword
+func (p *tsParser) parseInterface() *tsDecl {
+	decl := &tsDecl{name: p.ident()}
+	decl.params = p.parseTypeParams()
+	if p.accept("extends") {
+		for {
+			decl.extends = append(decl.extends, p.parsePrimary())
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	p.expect("{")
+	decl.tsType = p.parseObject()
+	return decl
+}
+
+// parseAlias parses a type alias declaration after the type keyword
+func (p *tsParser) parseAlias() *tsDecl {
+	decl := &tsDecl{name: p.ident()}
+	decl.params = p.parseTypeParams()
+	p.expect("=")
+	decl.tsType = p.parseType()
+	p.accept(";")
+	return decl
+}
+
+// parseTypeParams parses the names of type parameters, dropping their constraints and defaults
+func (p *tsParser) parseTypeParams() []string {
+	var params []string
+	if !p.accept("<") {
+		return nil
+	}
+	for !p.accept(">") {
+		params = append(params, p.ident())
+		if p.accept("extends") {
+			p.parseType()
+		}
+		if p.accept("=") {
+			p.parseType()
+		}
+		p.accept(",")
+	}
+	return params
+}
+
+// parseObject parses the members of an object type after its opening brace
+func (p *tsParser) parseObject() *tsType {
+	object := &tsType{kind: tsObject}
+	for !p.accept("}") {
+		if p.peek().kind == 0 {
+			p.fail("unterminated object type")
+		}
+		doc := p.peek().doc
+		if p.peek().text == "readonly" && p.peekAt(1).kind != 'p' {
+			p.next()
+		}
+		if p.accept("[") {
+			// Index signature, e.g. [key: string]: T
+			p.ident()
+			p.expect(":")
+			p.parseType()
+			p.expect("]")
+			p.expect(":")
+			object.index = p.parseType()
+			p.acceptSeparator()
+			continue
+		}
+		name := p.next()
+		if name.kind == 'p' {
+			p.fail("expected a property name, found %q", name.text)
+		}
+		member := &tsMember{name: name.text, doc: doc, optional: p.accept("?")}
+		if p.peek().text == "(" || p.peek().text == "<" {
+			// Methods aren't part of JSON
+			p.skipMember()
+			continue
+		}
+		p.expect(":")
+		member.tsType = p.parseType()
+		object.members = append(object.members, member)
+		p.acceptSeparator()
+	}
+	return object
+}
+
+func (p *tsParser) acceptSeparator() {
+	if !p.accept(";") {
+		p.accept(",")
+	}
+}
+
+// skipMember skips a method signature up to the end of the member
+func (p *tsParser) skipMember() {
+	depth := 0
+	for p.peek().kind != 0 {
+		token := p.peek()
+		if token.kind == 'p' {
+			switch token.text {
+			case "(", "[", "{", "<":
+				depth++
+			case ")", "]", ">":
+				depth--
+			case "}":
+				if depth == 0 {
+					return
+				}
+				depth--
+			case ";", ",":
+				if depth == 0 {
+					p.next()
+					return
+				}
+			}
+		}
+		if depth == 0 && token.newline && p.pos > 0 && p.tokens[p.pos-1].text != "=>" && p.tokens[p.pos-1].text != ":" {
+			return
+		}
+		p.next()
+	}
+}
+
+// parseType parses a type expression
+func (p *tsParser) parseType() *tsType {
+	p.accept("|")
+	union := &tsType{kind: tsUnion}
+	for {
+		union.args = append(union.args, p.parseIntersection())
+		if !p.accept("|") {
+			break
+		}
+	}
+	if len(union.args) == 1 {
+		return union.args[0]
+	}
+	return union
+}
+
+func (p *tsParser) parseIntersection() *tsType {
+	p.accept("&")
+	intersection := &tsType{kind: tsIntersection}
+	for {
+		intersection.args = append(intersection.args, p.parsePostfix())
+		if !p.accept("&") {
+			break
+		}
+	}
+	if len(intersection.args) == 1 {
+		return intersection.args[0]
+	}
+	return intersection
+}
+
+func (p *tsParser) parsePostfix() *tsType {
+	t := p.parsePrimary()
+	for p.peek().text == "[" && !p.peek().newline {
+		p.next()
+		if p.accept("]") {
+			t = &tsType{kind: tsArray, args: []*tsType{t}}
+			continue
+		}
+		// Indexed access types can't be resolved without a type checker
+		p.parseType()
+		p.expect("]")
+		t = &tsType{kind: tsKeyword, name: "any"}
+	}
+	return t
+}
+
+func (p *tsParser) parsePrimary() *tsType {
+	token := p.next()
+	switch token.kind {
+	case 's':
+		return &tsType{kind: tsString, name: token.text}
+	case 'n':
+		return &tsType{kind: tsNumber, name: token.text}
+	case 'p':
+		switch token.text {
+		case "-":
+			return &tsType{kind: tsNumber, name: "-" + p.next().text}
+		case "{":
+			return p.parseObject()
+		case "[":
+			tuple := &tsType{kind: tsTuple}
+			for !p.accept("]") {
+				p.accept("...")
+				if p.peek().kind == 'i' && (p.peekAt(1).text == ":" || p.peekAt(1).text == "?") {
+					p.next()
+					p.accept("?")
+					p.expect(":")
+				}
+				tuple.args = append(tuple.args, p.parseType())
+				p.accept(",")
+			}
+			return tuple
+		case "(", "<":
+			if token.text == "<" || p.isFunctionType() {
+				p.pos--
+				p.skipFunctionType()
+				return &tsType{kind: tsKeyword, name: "function"}
+			}
+			t := p.parseType()
+			p.expect(")")
+			return t
+		}
+		p.fail("unexpected %q", token.text)
+	case 0:
+		p.fail("unexpected end of file")
+	}
+	switch token.text {
+	case "typeof":
+		p.parsePrimary()
+		return &tsType{kind: tsKeyword, name: "any"}
+	case "keyof":
+		p.parsePostfix()
+		return &tsType{kind: tsKeyword, name: "string"}
+	case "readonly", "unique":
+		return p.parsePostfix()
+	case "new":
+		p.skipFunctionType()
+		return &tsType{kind: tsKeyword, name: "function"}
+	case "string", "number", "boolean", "bigint", "any", "unknown", "object", "null", "undefined", "void", "never", "symbol":
+		return &tsType{kind: tsKeyword, name: token.text}
+	case "true", "false":
+		return &tsType{kind: tsKeyword, name: "boolean"}
+	}
+	ref := &tsType{kind: tsRef, name: token.text}
+	for p.peek().text == "." && p.peekAt(1).kind == 'i' {
+		p.next()
+		ref.name += "." + p.next().text
+	}
+	if p.accept("<") {
+		for !p.accept(">") {
+			ref.args = append(ref.args, p.parseType())
+			p.accept(",")
+		}
+	}
+	return ref
+}
+
+// isFunctionType reports whether the parenthesis just consumed starts the parameters of a function type
+func (p *tsParser) isFunctionType() bool {
+	depth := 1
+	for i := p.pos; i < len(p.tokens); i++ {
+		switch p.tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i+1 < len(p.tokens) && p.tokens[i+1].text == "=>"
+			}
+		}
+	}
+	return false
+}
+
+// skipFunctionType skips a function type, starting at its type parameters or parameters
+func (p *tsParser) skipFunctionType() {
+	if p.peek().text == "<" {
+		for !p.accept(">") {
+			p.next()
+		}
+	}
+	p.expect("(")
+	for depth := 1; depth > 0; {
+		switch p.next().text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case "":
+			p.fail("unterminated function type")
+		}
+	}
+	p.expect("=>")
+	p.parseType()
+}
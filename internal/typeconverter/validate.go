@@ -0,0 +1,185 @@
+package typeconverter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxProblems limits the number of mismatches reported by Validate
+const maxProblems = 10
+
+// Validate checks a JSON document against the definition name of the schema and returns the mismatches found
+func (s *Schema) Validate(name string, data []byte) ([]string, error) {
+	def := s.Def(name)
+	if def == nil {
+		return nil, fmt.Errorf("no schema for %s", name)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	var problems []string
+	s.validate(def, value, name, &problems)
+	return problems, nil
+}
+
+// validate appends to problems the mismatches between value at path and schema
+func (s *Schema) validate(schema *Schema, value any, path string, problems *[]string) {
+	if len(*problems) >= maxProblems {
+		return
+	}
+	report := func(format string, args ...any) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+	if schema.Ref != "" {
+		def := s.Def(strings.TrimPrefix(schema.Ref, "#/$defs/"))
+		if def != nil {
+			s.validate(def, value, path, problems)
+		}
+		return
+	}
+	if len(schema.AnyOf) > 0 {
+		for _, option := range schema.AnyOf {
+			var optionProblems []string
+			s.validate(option, value, path, &optionProblems)
+			if len(optionProblems) == 0 {
+				return
+			}
+		}
+		report("%s doesn't match any of the allowed types", describe(value))
+		return
+	}
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, value) {
+		report("%s is not one of the constants", describe(value))
+		return
+	}
+	switch schema.Type {
+	case "string", "boolean", "null":
+		if jsonType(value) != schema.Type {
+			report("expected %s, got %s", schema.Type, describe(value))
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			report("expected number, got %s", describe(value))
+		}
+	case "integer":
+		if number, ok := value.(json.Number); !ok {
+			report("expected integer, got %s", describe(value))
+		} else if _, err := strconv.ParseInt(number.String(), 10, 64); err != nil {
+			if _, err := strconv.ParseUint(number.String(), 10, 64); err != nil {
+				report("expected integer, got %s", number)
+			}
+		}
+	case "array":
+		// encoding/json marshals nil slices as null
+		if value == nil {
+			return
+		}
+		items, ok := value.([]any)
+		if !ok {
+			report("expected array, got %s", describe(value))
+			return
+		}
+		for i, item := range items {
+			s.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case "object":
+		s.validateObject(schema, value, path, report, problems)
+	}
+}
+
+// validateObject checks the properties of a JSON object
+func (s *Schema) validateObject(schema *Schema, value any, path string, report func(string, ...any), problems *[]string) {
+	additional, isMap := schema.AdditionalProperties.(*Schema)
+	// encoding/json marshals nil maps as null
+	if value == nil && isMap {
+		return
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		report("expected object, got %s", describe(value))
+		return
+	}
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			report("missing property %q", name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(object)) {
+		propertyPath := path + "." + name
+		if property, ok := schema.Properties[name]; ok {
+			s.validate(property, object[name], propertyPath, problems)
+		} else if isMap {
+			if schema.PropertyNames != nil {
+				s.validate(schema.PropertyNames, name, propertyPath, problems)
+			}
+			s.validate(additional, object[name], propertyPath, problems)
+		} else if schema.AdditionalProperties == false {
+			report("unexpected property %q", name)
+		}
+	}
+}
+
+// jsonType returns the JSON Schema type name of a decoded value
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+// describe returns a short description of a decoded value for problem messages
+func describe(value any) string {
+	switch value := value.(type) {
+	case string:
+		if len(value) > 20 {
+			value = value[:20] + "..."
+		}
+		return strconv.Quote(value)
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return jsonType(value)
+}
+
+// containsValue reports whether a decoded value is one of the enum values
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if number, ok := v.(json.Number); ok {
+			if decoded, ok := value.(json.Number); ok && sameNumber(number, decoded) {
+				return true
+			}
+		} else if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sameNumber reports whether two JSON numbers have the same value
+func sameNumber(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	x, errA := a.Float64()
+	y, errB := b.Float64()
+	return errA == nil && errB == nil && x == y
+}
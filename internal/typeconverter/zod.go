@@ -0,0 +1,135 @@
+package typeconverter
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// zodSchemas returns a TypeScript module exporting a Zod schema for every definition of root, named <Name>Schema
+func zodSchemas(root *Schema) string {
+	var result strings.Builder
+	result.WriteString(generatedHeader)
+	result.WriteString("import { z } from \"zod\";\n")
+	for _, name := range root.defsOrder {
+		// Schemas that refer to themselves can't infer their own type
+		annotation := ""
+		if refersTo(root, root.Defs[name], name, map[string]bool{}) {
+			annotation = ": z.ZodTypeAny"
+		}
+		fmt.Fprintf(&result, "\nexport const %sSchema%s = %s;", name, annotation, zodSchema(root.Defs[name], ""))
+	}
+	return result.String()
+}
+
+// zodSchema returns the Zod expression of a schema, nested objects are indented with prefix
+func zodSchema(s *Schema, prefix string) string {
+	if s.Ref != "" {
+		// Definitions can be declared in any order when they are resolved lazily
+		return fmt.Sprintf("z.lazy(() => %sSchema)", strings.TrimPrefix(s.Ref, "#/$defs/"))
+	}
+	if len(s.AnyOf) == 2 && s.AnyOf[1].Type == "null" {
+		return zodSchema(s.AnyOf[0], prefix) + ".nullable()"
+	}
+	if len(s.AnyOf) > 0 {
+		options := make([]string, len(s.AnyOf))
+		for i, option := range s.AnyOf {
+			options[i] = zodSchema(option, prefix)
+		}
+		return "z.union([" + strings.Join(options, ", ") + "])"
+	}
+	if len(s.Enum) > 0 {
+		return zodEnum(s.Enum)
+	}
+	switch s.Type {
+	case "string":
+		return "z.string()"
+	case "number":
+		return "z.number()"
+	case "integer":
+		return "z.number().int()"
+	case "boolean":
+		return "z.boolean()"
+	case "null":
+		return "z.null()"
+	case "array":
+		return "z.array(" + zodSchema(s.Items, prefix) + ")"
+	case "object":
+		if additional, ok := s.AdditionalProperties.(*Schema); ok {
+			key := "z.string()"
+			if s.PropertyNames != nil {
+				key = zodSchema(s.PropertyNames, prefix)
+			}
+			return "z.record(" + key + ", " + zodSchema(additional, prefix) + ")"
+		}
+		if len(s.propertyOrder) == 0 {
+			return "z.object({}).strict()"
+		}
+		var fields []string
+		for _, name := range s.propertyOrder {
+			field := zodSchema(s.Properties[name], prefix+indent)
+			if !slices.Contains(s.Required, name) {
+				field += ".optional()"
+			}
+			if !isIdentifier(name) {
+				name = strconv.Quote(name)
+			}
+			fields = append(fields, prefix+indent+name+": "+field+",")
+		}
+		return "z.object({\n" + strings.Join(fields, "\n") + "\n" + prefix + "}).strict()"
+	}
+	return "z.any()"
+}
+
+// zodEnum returns the Zod expression of the enum values, z.enum for strings and literals otherwise
+func zodEnum(values []any) string {
+	literals := make([]string, len(values))
+	allStrings := true
+	for i, value := range values {
+		literal, _ := json.Marshal(value)
+		literals[i] = string(literal)
+		_, isString := value.(string)
+		allStrings = allStrings && isString
+	}
+	if allStrings {
+		return "z.enum([" + strings.Join(literals, ", ") + "])"
+	}
+	if len(literals) == 1 {
+		return "z.literal(" + literals[0] + ")"
+	}
+	for i, literal := range literals {
+		literals[i] = "z.literal(" + literal + ")"
+	}
+	return "z.union([" + strings.Join(literals, ", ") + "])"
+}
+
+// refersTo reports whether s refers to the definition name, directly or through other definitions
+func refersTo(root, s *Schema, name string, visited map[string]bool) bool {
+	if s == nil {
+		return false
+	}
+	if s.Ref != "" {
+		ref := strings.TrimPrefix(s.Ref, "#/$defs/")
+		if ref == name {
+			return true
+		}
+		if visited[ref] {
+			return false
+		}
+		visited[ref] = true
+		return refersTo(root, root.Defs[ref], name, visited)
+	}
+	additional, _ := s.AdditionalProperties.(*Schema)
+	children := append([]*Schema{s.Items, s.PropertyNames, additional}, s.AnyOf...)
+	for _, property := range s.Properties {
+		children = append(children, property)
+	}
+	for _, child := range children {
+		if refersTo(root, child, name, visited) {
+			return true
+		}
+	}
+	return false
+}
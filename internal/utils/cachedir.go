@@ -62,6 +62,17 @@ func GetServerBuildCacheDir(fileName string) (string, error) {
 	return routeCacheDir, err
 }
 
+// GetCodeCacheDir returns the path to the directory of the code compiled by the JS runtimes
+func GetCodeCacheDir() (string, error) {
+	cacheDir, err := createCacheDirIfNotExists()
+	if err != nil {
+		return "", err
+	}
+	codeCacheDir := filepath.Join(cacheDir, "code_cache")
+	err = os.MkdirAll(codeCacheDir, os.ModePerm)
+	return codeCacheDir, err
+}
+
 // GetCSSCacheDir returns the path to the server build cache directory for the given route
 func GetCSSCacheDir() (string, error) {
 	cacheDir, err := createCacheDirIfNotExists()
@@ -0,0 +1,84 @@
+package go_ssr
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"reflect"
+)
+
+// Page is a page file bound to the type of its props
+// Declare pages once at startup and render them with typed props:
+//
+//	var Home = gossr.NewPage[models.IndexRouteProps](engine, "Home.tsx")
+//	response := Home.Render(ctx, models.IndexRouteProps{InitialCount: 1})
+type Page[T any] struct {
+	engine *Engine
+	file   string
+
+	Title    string            // The title of the page, unless one is set with WithRenderConfig
+	MetaTags map[string]string // The meta tags of the page, unless they are set with WithRenderConfig
+}
+
+// NewPage declares the page at file, relative to the frontend dir, rendered with props of type T
+// It panics if the file doesn't exist. In development the Routes interface of the generated types maps file to T.
+func NewPage[T any](engine *Engine, file string) *Page[T] {
+	file = path.Clean(filepath.ToSlash(file))
+	if !checkPathExists(path.Join(engine.Config.FrontendDir, file)) {
+		panic(fmt.Sprintf("gossr: page %s does not exist in %s", file, engine.Config.FrontendDir))
+	}
+	propsType := reflect.TypeFor[T]()
+	for propsType.Kind() == reflect.Pointer {
+		propsType = propsType.Elem()
+	}
+	engine.registerPage(file, propsType.Name())
+	return &Page[T]{engine: engine, file: file}
+}
+
+// File returns the page file, relative to the frontend dir
+func (page *Page[T]) File() string {
+	return page.file
+}
+
+// Render renders the page with props
+// The title, meta tags and request path set on ctx with WithRenderConfig are used if present
+func (page *Page[T]) Render(ctx context.Context, props T) []byte {
+	renderConfig, _ := ctx.Value(renderConfigKey{}).(RenderConfig)
+	if renderConfig.Title == "" {
+		renderConfig.Title = page.Title
+	}
+	if renderConfig.MetaTags == nil {
+		renderConfig.MetaTags = page.MetaTags
+	}
+	renderConfig.File = page.file
+	renderConfig.Props = props
+	return page.engine.RenderRoute(renderConfig)
+}
+
+type renderConfigKey struct{}
+
+// WithRenderConfig returns a copy of ctx carrying the title, meta tags and request path of config for Page.Render
+// The file and props of config are ignored
+func WithRenderConfig(ctx context.Context, config RenderConfig) context.Context {
+	return context.WithValue(ctx, renderConfigKey{}, config)
+}
+
+// registerPage records the props type name of a page file for the Routes interface of the generated types
+func (engine *Engine) registerPage(file, propsTypeName string) {
+	engine.pagesMu.Lock()
+	if engine.pages == nil {
+		engine.pages = make(map[string]string)
+	}
+	engine.pages[file] = propsTypeName
+	engine.pagesMu.Unlock()
+	engine.pagesChanged()
+}
+
+// registeredPages returns a copy of the props type names of the declared pages by file
+func (engine *Engine) registeredPages() map[string]string {
+	engine.pagesMu.Lock()
+	defer engine.pagesMu.Unlock()
+	return maps.Clone(engine.pages)
+}
@@ -0,0 +1,38 @@
+package go_ssr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type IndexRouteProps struct {
+	InitialCount int `json:"initialCount"`
+}
+
+func TestNewPage(t *testing.T) {
+	config := Config{
+		AppEnv:              "development",
+		FrontendDir:         "./examples/frontend/src",
+		GeneratedTypesPath:  filepath.Join(t.TempDir(), "generated.d.ts"),
+		PropsStructsPath:    "./examples/gin/models/props.go",
+		HotReloadServerPort: 4003,
+	}
+	engine, err := New(config)
+	assert.Nil(t, err, "gossr.New should not return an error, got %v", err)
+	defer engine.Shutdown(context.Background())
+
+	assert.Panics(t, func() { NewPage[IndexRouteProps](engine, "Missing.tsx") }, "NewPage should panic if the file does not exist")
+
+	home := NewPage[*IndexRouteProps](engine, "./Home.tsx")
+	assert.Equal(t, "Home.tsx", home.File())
+	assert.Eventually(t, func() bool {
+		contents, _ := os.ReadFile(config.GeneratedTypesPath)
+		return strings.Contains(string(contents), "export interface Routes {\n    \"Home.tsx\": IndexRouteProps;\n}")
+	}, 5*time.Second, 50*time.Millisecond, "Generated types should map Home.tsx to IndexRouteProps")
+}
@@ -32,8 +32,9 @@ func (engine *Engine) RenderRoute(renderConfig RenderConfig) []byte {
 
 	props, err := propsToString(renderConfig.Props)
 	if err != nil {
-		return html.RenderError(err, routeID)
+		return engine.renderErrorPage(err, routeID)
 	}
+	engine.validateProps(renderConfig.File, renderConfig.Props, props)
 	task := renderTask{
 		engine:   engine,
 		logger:   engine.Logger,
@@ -44,7 +45,7 @@ func (engine *Engine) RenderRoute(renderConfig RenderConfig) []byte {
 	}
 	renderedHTML, css, js, err := task.Start()
 	if err != nil {
-		return html.RenderError(err, task.routeID)
+		return engine.renderErrorPage(err, task.routeID)
 	}
 
 	// Add __requestPath to props for client hydration
@@ -62,6 +63,8 @@ func (engine *Engine) RenderRoute(renderConfig RenderConfig) []byte {
 		RouteID:    task.routeID,
 		ServerHTML: template.HTML(renderedHTML),
 		PropsJSON:  template.JS(propsWithRequestPath), // SSR props for client hydration (with __requestPath)
+		ImportMap:  engine.importMapTag(),
+		HotReload:  engine.hotReloadParams(),
 	}
 
 	// External JS/CSS file mode: write to files and use <script src>/<link href>
@@ -1,6 +1,7 @@
 package go_ssr
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -44,25 +45,44 @@ func (rt *renderTask) Start() (string, string, string, error) {
 	go rt.doRender("server")
 	go rt.doRender("client")
 
-	// Wait for both to finish
+	// Wait for both to finish, so neither goroutine is left blocked on its channel
 	srResult := <-rt.serverRenderResult
+	crResult := <-rt.clientRenderResult
+
+	// Set the parent file dependencies so that the cache can be invalidated a dependency changes
+	// This also runs for failed builds so that fixing the broken file reloads the error page
+	dependencies := crResult.dependencies
+	if buildErr := buildErrorOf(srResult.err, crResult.err); buildErr != nil && buildErr.File != "" {
+		dependencies = append(dependencies, buildErr.File)
+	}
+	if crResult.err == nil || len(dependencies) > 0 {
+		go func() {
+			if err := rt.engine.Cache.SetParentFileDependencies(rt.filePath, dependencies); err != nil {
+				rt.logger.Error("Failed to set parent file dependencies", "error", err)
+			}
+		}()
+	}
+
 	if srResult.err != nil {
 		rt.logger.Error("Failed to build for server", "error", srResult.err)
 		return "", "", "", srResult.err
 	}
-	crResult := <-rt.clientRenderResult
 	if crResult.err != nil {
 		rt.logger.Error("Failed to build for client", "error", crResult.err)
 		return "", "", "", crResult.err
 	}
+	return srResult.html, srResult.css, crResult.js, nil
+}
 
-	// Set the parent file dependencies so that the cache can be invalidated a dependency changes
-	go func() {
-		if err := rt.engine.Cache.SetParentFileDependencies(rt.filePath, crResult.dependencies); err != nil {
-			rt.logger.Error("Failed to set parent file dependencies", "error", err)
+// buildErrorOf returns the first esbuild error among errs
+func buildErrorOf(errs ...error) *reactbuilder.BuildError {
+	for _, err := range errs {
+		var buildErr *reactbuilder.BuildError
+		if errors.As(err, &buildErr) {
+			return buildErr
 		}
-	}()
-	return srResult.html, srResult.css, crResult.js, nil
+	}
+	return nil
 }
 
 func (rt *renderTask) doRender(buildType string) {
@@ -83,6 +103,7 @@ func (rt *renderTask) doRender(buildType string) {
 			propsJSON = propsJSON[:len(propsJSON)-1] + fmt.Sprintf(`, "__requestPath": "%s" }`, rt.config.RequestPath)
 		}
 		renderedHTML, err := rt.renderReactToHTMLWithProps(rt.engine.CachedServerSPAJS, propsJSON)
+		err = remapJSError(err, rt.engine.CachedServerSPASourceMap, 0)
 		if err != nil {
 			rt.logger.Error("SPA server render error", "error", err, "requestPath", rt.config.RequestPath)
 		}
@@ -91,32 +112,39 @@ func (rt *renderTask) doRender(buildType string) {
 		return
 	}
 
-	// Check if the build is in the cache
-	build, buildFound, err := rt.getBuildFromCache(buildType)
+	build, err := rt.getBuild(buildType)
 	if err != nil {
-		rt.logger.Error("Failed to get build from cache", "error", err, "buildType", buildType)
-	}
-	if !buildFound {
-		// Build the file if it's not in the cache
-		newBuild, err := rt.buildFile(buildType)
-		if err != nil {
-			rt.handleBuildError(err, buildType)
-			return
-		}
-		rt.updateBuildCache(newBuild, buildType)
-		build = newBuild
+		rt.handleBuildError(err, buildType)
+		return
 	}
 	// JS is built without props so that the props can be injected into cached JS builds
-	js := injectProps(build.JS, rt.props)
 	if buildType == "server" {
-		// Execute the JS using the pooled runtime
-		renderedHTML, err := rt.renderReactToHTML(js)
+		// Execute the cached bundle using the pooled runtime, which compiles it once
+		renderedHTML, err := rt.renderReactToHTMLWithProps(build.JS, rt.props)
+		err = remapJSError(err, build.SourceMap, 0)
 		rt.serverRenderResult <- serverRenderResult{html: renderedHTML, css: build.CSS, err: err}
 	} else {
-		rt.clientRenderResult <- clientRenderResult{js: js, dependencies: build.Dependencies}
+		rt.clientRenderResult <- clientRenderResult{js: injectProps(build.JS, rt.props), dependencies: build.Dependencies}
 	}
 }
 
+// getBuild returns the build of the file from the cache, building and caching it if it isn't there
+func (rt *renderTask) getBuild(buildType string) (reactbuilder.BuildResult, error) {
+	build, buildFound, err := rt.getBuildFromCache(buildType)
+	if err != nil {
+		rt.logger.Error("Failed to get build from cache", "error", err, "buildType", buildType)
+	}
+	if buildFound {
+		return build, nil
+	}
+	build, err = rt.buildFile(buildType)
+	if err != nil {
+		return reactbuilder.BuildResult{}, err
+	}
+	rt.updateBuildCache(build, buildType)
+	return build, nil
+}
+
 // getBuild returns the build from the cache if it exists
 func (rt *renderTask) getBuildFromCache(buildType string) (reactbuilder.BuildResult, bool, error) {
 	if buildType == "server" {
@@ -133,9 +161,9 @@ func (rt *renderTask) buildFile(buildType string) (reactbuilder.BuildResult, err
 		return reactbuilder.BuildResult{}, err
 	}
 	if buildType == "server" {
-		return reactbuilder.BuildServer(buildContents, rt.engine.Config.FrontendDir, rt.engine.Config.AssetRoute)
+		return reactbuilder.BuildServer(buildContents, rt.engine.Config.FrontendDir, rt.engine.Config.AssetRoute, rt.engine.RuntimePool.ESTarget())
 	} else {
-		return reactbuilder.BuildClient(buildContents, rt.engine.Config.FrontendDir, rt.engine.Config.AssetRoute, rt.engine.IsProduction())
+		return reactbuilder.BuildClient(buildContents, rt.engine.Config.FrontendDir, rt.engine.Config.AssetRoute, rt.engine.IsProduction(), rt.engine.clientExternals(), rt.engine.hmrEnabled)
 	}
 }
 
@@ -177,9 +205,9 @@ func (rt *renderTask) updateBuildCache(build reactbuilder.BuildResult, buildType
 	}
 }
 
-// injectProps injects the props into the already compiled JS
+// injectProps injects the props into the already compiled client JS
 func injectProps(compiledJS, props string) string {
-	return fmt.Sprintf(`var props = %s; %s`, props, compiledJS)
+	return fmt.Sprintf("var props = %s;\n%s", props, compiledJS)
 }
 
 // injectSPAProps injects props with __requestPath for SPA server rendering
@@ -187,11 +215,6 @@ func injectSPAProps(compiledJS, requestPath string) string {
 	return fmt.Sprintf(`var props = { __requestPath: "%s" }; %s`, requestPath, compiledJS)
 }
 
-// renderReactToHTML executes the server JS using the pooled runtime
-func (rt *renderTask) renderReactToHTML(js string) (string, error) {
-	return rt.engine.RuntimePool.Execute(js)
-}
-
 // renderReactToHTMLWithProps executes the server JS with cached bundle + props
 // The bundle is compiled once and cached; only props change per request
 func (rt *renderTask) renderReactToHTMLWithProps(bundle, propsJSON string) (string, error) {
@@ -0,0 +1,33 @@
+package go_ssr
+
+import (
+	"github.com/yejune/gotossr/internal/sourcemap"
+)
+
+// remappedError is a JS error whose stack trace points to the original source files
+type remappedError struct {
+	message string
+	err     error
+}
+
+func (e *remappedError) Error() string {
+	return e.message
+}
+
+func (e *remappedError) Unwrap() error {
+	return e.err
+}
+
+// remapJSError rewrites positions in the generated bundle to the original .tsx/.ts files.
+// lineOffset is the number of lines that were prepended to the bundle before execution.
+func remapJSError(err error, sourceMap string, lineOffset int) error {
+	if err == nil || sourceMap == "" {
+		return err
+	}
+	// Source maps are only parsed when an error occurs, so successful renders pay nothing
+	m, parseErr := sourcemap.Parse([]byte(sourceMap), "/")
+	if parseErr != nil {
+		return err
+	}
+	return &remappedError{message: m.RemapStack(err.Error(), lineOffset), err: err}
+}
@@ -0,0 +1,28 @@
+import React from "react";
+
+interface HomeProps {
+  initialCount: number;
+}
+
+function Counter(props: { count: number; onIncrement: () => void }) {
+  return <button onClick={props.onIncrement}>Count: {props.count}</button>;
+}
+
+// Written in ES5 apart from the syntax esbuild lowers, so that Duktape can render it too
+export default function Home(props: HomeProps) {
+  var initialCount = props.initialCount;
+  var items = [1, 2, 3].map((n) => n * initialCount);
+  return (
+    <div className="home">
+      <h1 title={`Count ${initialCount}`}>Go &amp; React</h1>
+      <Counter count={initialCount} onIncrement={() => {}} />
+      <ul>
+        {items.map((item) => (
+          <li>{item}</li>
+        ))}
+      </ul>
+      {initialCount > 40 && <p>{"<large>"}</p>}
+      <p>{0.1 + 0.2}</p>
+    </div>
+  );
+}
@@ -0,0 +1,133 @@
+package go_ssr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yejune/gotossr/internal/reactbuilder"
+	"github.com/yejune/gotossr/internal/utils"
+)
+
+// Warmup builds the server and client bundles of files ahead of their first render and compiles the server
+// bundles in the idle JS runtimes, so the first requests after a deploy don't pay for it.
+// Files are relative to FrontendDir like RenderConfig.File and may be glob patterns, e.g. "pages/*.tsx".
+// Without files, the pages declared with NewPage are warmed up.
+func (engine *Engine) Warmup(ctx context.Context, files ...string) error {
+	start := time.Now()
+	if len(files) == 0 {
+		for file := range engine.registeredPages() {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+	}
+	files, err := engine.expandWarmupFiles(files)
+	if err != nil {
+		return err
+	}
+
+	// Files are built in parallel, each building its server and client bundles concurrently like renders do
+	bundles := make([]string, len(files))
+	errs := make([]error, len(files))
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			bundles[i], errs[i] = engine.warmupFile(file)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if engine.CachedServerSPAJS != "" {
+		bundles = append(bundles, engine.CachedServerSPAJS)
+	}
+	for _, bundle := range bundles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := engine.RuntimePool.Precompile(bundle); err != nil {
+			return fmt.Errorf("failed to compile server bundle: %w", err)
+		}
+	}
+
+	engine.Logger.Info("Warmed up", "files", len(files), "duration", time.Since(start))
+	return nil
+}
+
+// expandWarmupFiles replaces the glob patterns among files with the files they match
+func (engine *Engine) expandWarmupFiles(files []string) ([]string, error) {
+	var expanded []string
+	for _, file := range files {
+		if !strings.ContainsAny(file, "*?[") {
+			expanded = append(expanded, file)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(engine.Config.FrontendDir, file))
+		if err != nil {
+			return nil, fmt.Errorf("invalid warmup pattern %s: %w", file, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("warmup pattern %s matches no files in %s", file, engine.Config.FrontendDir)
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(engine.Config.FrontendDir, match)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, filepath.ToSlash(rel))
+		}
+	}
+	return expanded, nil
+}
+
+// warmupFile builds and caches the bundles of a file, returning its server bundle
+func (engine *Engine) warmupFile(file string) (string, error) {
+	filePath := filepath.ToSlash(utils.GetFullFilePath(engine.Config.FrontendDir + "/" + file))
+	task := renderTask{
+		engine:   engine,
+		logger:   engine.Logger,
+		routeID:  generateRouteID(filePath),
+		filePath: filePath,
+	}
+	if err := engine.Cache.SetParentFile(task.routeID, filePath); err != nil {
+		engine.Logger.Error("Failed to set parent file", "error", err)
+	}
+
+	var serverBuild reactbuilder.BuildResult
+	var serverErr error
+	done := make(chan struct{})
+	go func() {
+		serverBuild, serverErr = task.getBuild("server")
+		close(done)
+	}()
+	clientBuild, clientErr := task.getBuild("client")
+	<-done
+
+	if clientErr == nil {
+		// Lets hot reload invalidate the builds when a dependency changes before the first render
+		if err := engine.Cache.SetParentFileDependencies(filePath, clientBuild.Dependencies); err != nil {
+			engine.Logger.Error("Failed to set parent file dependencies", "error", err)
+		}
+	}
+	if err := errors.Join(serverErr, clientErr); err != nil {
+		return "", fmt.Errorf("failed to warm up %s: %w", file, err)
+	}
+	return serverBuild.JS, nil
+}
@@ -0,0 +1,57 @@
+package go_ssr
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yejune/gotossr/internal/utils"
+)
+
+func TestEngine_Warmup(t *testing.T) {
+	engine, err := New(Config{
+		AppEnv:      "production",
+		FrontendDir: "./examples/frontend/src",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Shutdown(context.Background())
+
+	files, err := engine.expandWarmupFiles([]string{"Home.tsx", "*.tsx"})
+	assert.Nil(t, err, "expandWarmupFiles should not return an error")
+	assert.Contains(t, files[1:], "Home.tsx", "Patterns should be expanded to the files they match")
+	assert.Equal(t, "Home.tsx", files[0], "Files should be kept")
+
+	err = engine.Warmup(context.Background(), "missing/*.tsx")
+	assert.ErrorContains(t, err, "matches no files", "Warmup should fail for patterns matching nothing")
+
+	if _, err := os.Stat("./examples/frontend/node_modules"); err != nil {
+		t.Skip("Dependencies of the example aren't installed, run npm install in ./examples/frontend")
+	}
+	err = engine.Warmup(context.Background(), "Home.tsx")
+	assert.Nil(t, err, "Warmup should not return an error")
+	_, found, _ := engine.Cache.GetServerBuild(utils.GetFullFilePath("./examples/frontend/src/Home.tsx"))
+	assert.True(t, found, "Warmup should cache the server build")
+	_, found, _ = engine.Cache.GetClientBuild(utils.GetFullFilePath("./examples/frontend/src/Home.tsx"))
+	assert.True(t, found, "Warmup should cache the client build")
+}
+
+func TestNew_WarmupFailed(t *testing.T) {
+	_, err := New(Config{
+		AppEnv:              "development",
+		FrontendDir:         "./examples/frontend/src",
+		HotReloadServerPort: 4003,
+		WarmupFiles:         []string{"missing/*.tsx"},
+	})
+	assert.ErrorContains(t, err, "matches no files", "New should fail when the warmup fails")
+
+	// The hot reload server should have been stopped
+	listener, err := net.Listen("tcp", "127.0.0.1:4003")
+	assert.Nil(t, err, "The hot reload port should be released")
+	if listener != nil {
+		listener.Close()
+	}
+}